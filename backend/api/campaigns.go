@@ -0,0 +1,533 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var campaignCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,63}$`)
+
+type campaignDocument struct {
+	ID          primitive.ObjectID   `bson:"_id"`
+	Code        string               `bson:"code"`
+	Title       string               `bson:"title"`
+	Description string               `bson:"description,omitempty"`
+	Prefectures []string             `bson:"prefectures,omitempty"`
+	Categories  []string             `bson:"categories,omitempty"`
+	StoreIDs    []primitive.ObjectID `bson:"storeIds,omitempty"`
+	StartsAt    time.Time            `bson:"startsAt"`
+	EndsAt      time.Time            `bson:"endsAt"`
+	BonusAmount int                  `bson:"bonusAmount"`
+	Enabled     bool                 `bson:"enabled"`
+	CreatedAt   time.Time            `bson:"createdAt"`
+	UpdatedAt   time.Time            `bson:"updatedAt"`
+}
+
+type reviewCampaignDocument struct {
+	ID          primitive.ObjectID `bson:"id"`
+	Code        string             `bson:"code"`
+	BonusAmount int                `bson:"bonusAmount"`
+}
+
+type campaignRequest struct {
+	Code        string   `json:"code"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Prefectures []string `json:"prefectures"`
+	Categories  []string `json:"categories"`
+	StoreIDs    []string `json:"storeIds"`
+	StartsAt    string   `json:"startsAt"`
+	EndsAt      string   `json:"endsAt"`
+	BonusAmount int      `json:"bonusAmount"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+type campaignStoreResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	BranchName string `json:"branchName,omitempty"`
+	Prefecture string `json:"prefecture,omitempty"`
+}
+
+type campaignResponse struct {
+	ID          string                  `json:"id"`
+	Code        string                  `json:"code"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description,omitempty"`
+	Prefectures []string                `json:"prefectures"`
+	Categories  []string                `json:"categories"`
+	Stores      []campaignStoreResponse `json:"stores"`
+	StartsAt    time.Time               `json:"startsAt"`
+	EndsAt      time.Time               `json:"endsAt"`
+	BonusAmount int                     `json:"bonusAmount"`
+	Enabled     bool                    `json:"enabled"`
+}
+
+type campaignReportResponse struct {
+	Campaign     campaignResponse `json:"campaign"`
+	Submissions  int              `json:"submissions"`
+	ByStatus     map[string]int   `json:"byStatus"`
+	Payouts      int              `json:"payouts"`
+	PayoutAmount int              `json:"payoutAmount"`
+	Held         int              `json:"held"`
+}
+
+func campaignMatches(campaign campaignDocument, review reviewDocument, store storeDocument, at time.Time) bool {
+	if !campaign.Enabled || at.Before(campaign.StartsAt) || !at.Before(campaign.EndsAt) {
+		return false
+	}
+	if len(campaign.Prefectures) > 0 && !contains(campaign.Prefectures, strings.TrimSpace(store.Prefecture)) {
+		return false
+	}
+	if len(campaign.Categories) > 0 && !contains(campaign.Categories, canonicalIndustryCode(review.IndustryCode)) {
+		return false
+	}
+	if len(campaign.StoreIDs) > 0 {
+		matched := false
+		for _, id := range campaign.StoreIDs {
+			if id == review.StoreID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *server) loadActiveCampaigns(ctx context.Context, at time.Time) ([]campaignDocument, error) {
+	cursor, err := s.campaigns.Find(ctx, bson.M{
+		"enabled":  true,
+		"startsAt": bson.M{"$lte": at},
+		"endsAt":   bson.M{"$gt": at},
+	}, options.Find().SetSort(bson.D{{Key: "bonusAmount", Value: -1}, {Key: "endsAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	campaigns := []campaignDocument{}
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+func (s *server) matchReviewCampaign(ctx context.Context, review reviewDocument, store storeDocument) *reviewCampaignDocument {
+	campaigns, err := s.loadActiveCampaigns(ctx, review.CreatedAt)
+	if err != nil {
+		s.logger.Printf("キャンペーンの取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+		return nil
+	}
+	for _, campaign := range campaigns {
+		if campaignMatches(campaign, review, store, review.CreatedAt) {
+			return &reviewCampaignDocument{
+				ID:          campaign.ID,
+				Code:        campaign.Code,
+				BonusAmount: campaign.BonusAmount,
+			}
+		}
+	}
+	return nil
+}
+
+// rematchReviewCampaign は店舗か業種が変わったときだけ、投稿時点の条件でキャンペーンを判定し直す。
+func (s *server) rematchReviewCampaign(ctx context.Context, existing, candidate reviewDocument, store storeDocument) (*reviewCampaignDocument, bool) {
+	if existing.StoreID == candidate.StoreID && canonicalIndustryCode(existing.IndustryCode) == canonicalIndustryCode(candidate.IndustryCode) {
+		return existing.Campaign, false
+	}
+	return s.matchReviewCampaign(ctx, candidate, store), true
+}
+
+func withCampaignUpdate(set bson.M, campaign *reviewCampaignDocument, rematched bool) bson.M {
+	update := bson.M{"$set": set}
+	if !rematched {
+		return update
+	}
+	if campaign != nil {
+		set["campaign"] = campaign
+	} else {
+		update["$unset"] = bson.M{"campaign": ""}
+	}
+	return update
+}
+
+func reviewCampaignCode(review reviewDocument) string {
+	if review.Campaign == nil {
+		return ""
+	}
+	return review.Campaign.Code
+}
+
+func reviewCampaignBonus(review reviewDocument) int {
+	if review.Campaign == nil {
+		return 0
+	}
+	return review.Campaign.BonusAmount
+}
+
+func (s *server) campaignToResponse(ctx context.Context, doc campaignDocument) campaignResponse {
+	response := campaignResponse{
+		ID:          doc.ID.Hex(),
+		Code:        doc.Code,
+		Title:       doc.Title,
+		Description: doc.Description,
+		Prefectures: doc.Prefectures,
+		Categories:  doc.Categories,
+		Stores:      []campaignStoreResponse{},
+		StartsAt:    doc.StartsAt,
+		EndsAt:      doc.EndsAt,
+		BonusAmount: doc.BonusAmount,
+		Enabled:     doc.Enabled,
+	}
+	if response.Prefectures == nil {
+		response.Prefectures = []string{}
+	}
+	if response.Categories == nil {
+		response.Categories = []string{}
+	}
+	if len(doc.StoreIDs) > 0 {
+		stores, err := s.loadStoresMap(ctx, doc.StoreIDs)
+		if err != nil {
+			s.logger.Printf("キャンペーン対象店舗の取得に失敗 id=%s err=%v", doc.ID.Hex(), err)
+		}
+		for _, id := range doc.StoreIDs {
+			store, ok := stores[id]
+			if !ok {
+				continue
+			}
+			response.Stores = append(response.Stores, campaignStoreResponse{
+				ID:         store.ID.Hex(),
+				Name:       store.Name,
+				BranchName: store.BranchName,
+				Prefecture: store.Prefecture,
+			})
+		}
+	}
+	return response
+}
+
+func parseCampaignRequest(req campaignRequest, loc *time.Location) (campaignDocument, error) {
+	doc := campaignDocument{
+		Code:        strings.ToLower(strings.TrimSpace(req.Code)),
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		BonusAmount: req.BonusAmount,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if !campaignCodePattern.MatchString(doc.Code) {
+		return doc, errors.New("codeは英小文字・数字・ハイフン・アンダースコアの2〜64文字で指定してください")
+	}
+	if doc.Title == "" {
+		return doc, errors.New("タイトルは必須です")
+	}
+	if doc.BonusAmount < 0 {
+		return doc, errors.New("ボーナス額は0以上で指定してください")
+	}
+	for _, prefecture := range req.Prefectures {
+		if trimmed := strings.TrimSpace(prefecture); trimmed != "" && !contains(doc.Prefectures, trimmed) {
+			doc.Prefectures = append(doc.Prefectures, trimmed)
+		}
+	}
+	doc.Categories = canonicalIndustryCodes(req.Categories)
+	for _, raw := range req.StoreIDs {
+		id, err := primitive.ObjectIDFromHex(strings.TrimSpace(raw))
+		if err != nil {
+			return doc, errors.New("店舗IDの形式が不正です: " + raw)
+		}
+		doc.StoreIDs = append(doc.StoreIDs, id)
+	}
+
+	var err error
+	if doc.StartsAt, err = parseCampaignTime(req.StartsAt, loc); err != nil {
+		return doc, errors.New("startsAtの形式が不正です")
+	}
+	if doc.EndsAt, err = parseCampaignEnd(req.EndsAt, loc); err != nil {
+		return doc, errors.New("endsAtの形式が不正です")
+	}
+	if !doc.EndsAt.After(doc.StartsAt) {
+		return doc, errors.New("終了日時は開始日時より後にしてください")
+	}
+	return doc, nil
+}
+
+func parseCampaignTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// parseCampaignEnd は日付のみの終了日をその日の終わりまで含める。
+func parseCampaignEnd(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.AddDate(0, 0, 1), nil
+}
+
+func (s *server) campaignListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		campaigns, err := s.loadActiveCampaigns(ctx, time.Now().In(s.location))
+		if err != nil {
+			s.logger.Printf("キャンペーン一覧の取得に失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン一覧の取得に失敗しました"})
+			return
+		}
+		items := make([]campaignResponse, 0, len(campaigns))
+		for _, campaign := range campaigns {
+			items = append(items, s.campaignToResponse(ctx, campaign))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminCampaignListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.campaigns.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "startsAt", Value: -1}}))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン一覧の取得に失敗しました"})
+			return
+		}
+		var campaigns []campaignDocument
+		if err := cursor.All(ctx, &campaigns); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン一覧の取得に失敗しました"})
+			return
+		}
+		items := make([]campaignResponse, 0, len(campaigns))
+		for _, campaign := range campaigns {
+			items = append(items, s.campaignToResponse(ctx, campaign))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminCampaignCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req campaignRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		doc, err := parseCampaignRequest(req, s.location)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := s.campaigns.FindOne(ctx, bson.M{"code": doc.Code}).Err(); err == nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "同じcodeのキャンペーンが既に存在します"})
+			return
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの確認に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		doc.ID = primitive.NewObjectID()
+		doc.CreatedAt = now
+		doc.UpdatedAt = now
+		if _, err := s.campaigns.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("admin campaign create failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの作成に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin campaign create success id=%s code=%s", doc.ID.Hex(), doc.Code)
+		s.writeJSON(w, http.StatusCreated, s.campaignToResponse(ctx, doc))
+	}
+}
+
+func (s *server) adminCampaignUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "キャンペーンIDの形式が不正です"})
+			return
+		}
+		var req campaignRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		doc, err := parseCampaignRequest(req, s.location)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := s.campaigns.FindOne(ctx, bson.M{"code": doc.Code, "_id": bson.M{"$ne": objectID}}).Err(); err == nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "同じcodeのキャンペーンが既に存在します"})
+			return
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの確認に失敗しました"})
+			return
+		}
+
+		update := bson.M{
+			"code":        doc.Code,
+			"title":       doc.Title,
+			"description": doc.Description,
+			"prefectures": doc.Prefectures,
+			"categories":  doc.Categories,
+			"storeIds":    doc.StoreIDs,
+			"startsAt":    doc.StartsAt,
+			"endsAt":      doc.EndsAt,
+			"bonusAmount": doc.BonusAmount,
+			"updatedAt":   time.Now().In(s.location),
+		}
+		if req.Enabled != nil {
+			update["enabled"] = *req.Enabled
+		}
+
+		var updated campaignDocument
+		result := s.campaigns.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "キャンペーンが見つかりません"})
+				return
+			}
+			s.logger.Printf("admin campaign update failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの更新に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.campaignToResponse(ctx, updated))
+	}
+}
+
+func (s *server) adminCampaignDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "キャンペーンIDの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		attached, err := s.reviews.CountDocuments(ctx, bson.M{"campaign.id": objectID})
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの確認に失敗しました"})
+			return
+		}
+		if attached > 0 {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "投稿が紐付いたキャンペーンは削除できません。無効化してください"})
+			return
+		}
+		result, err := s.campaigns.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			s.logger.Printf("admin campaign delete failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーンの削除に失敗しました"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "キャンペーンが見つかりません"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) adminCampaignReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := s.campaigns.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "startsAt", Value: -1}}))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン一覧の取得に失敗しました"})
+			return
+		}
+		var campaigns []campaignDocument
+		if err := cursor.All(ctx, &campaigns); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン一覧の取得に失敗しました"})
+			return
+		}
+
+		statsCursor, err := s.reviews.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"campaign.id": bson.M{"$exists": true}}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":   bson.M{"campaign": "$campaign.id", "status": "$status", "reward": "$reward.status"},
+				"count": bson.M{"$sum": 1},
+				"paid":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$reward.status", rewardStatusSent}}, "$reward.amount", 0}}},
+			}}},
+		})
+		if err != nil {
+			s.logger.Printf("admin campaign report aggregate failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン実績の集計に失敗しました"})
+			return
+		}
+		var rows []struct {
+			Key struct {
+				Campaign primitive.ObjectID `bson:"campaign"`
+				Status   string             `bson:"status"`
+				Reward   string             `bson:"reward"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+			Paid  int `bson:"paid"`
+		}
+		if err := statsCursor.All(ctx, &rows); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "キャンペーン実績の集計に失敗しました"})
+			return
+		}
+
+		reports := make(map[primitive.ObjectID]*campaignReportResponse, len(campaigns))
+		items := make([]*campaignReportResponse, 0, len(campaigns))
+		for _, campaign := range campaigns {
+			report := &campaignReportResponse{
+				Campaign: s.campaignToResponse(ctx, campaign),
+				ByStatus: map[string]int{},
+			}
+			reports[campaign.ID] = report
+			items = append(items, report)
+		}
+		for _, row := range rows {
+			report, ok := reports[row.Key.Campaign]
+			if !ok {
+				continue
+			}
+			report.Submissions += row.Count
+			report.ByStatus[row.Key.Status] += row.Count
+			switch row.Key.Reward {
+			case rewardStatusSent:
+				report.Payouts += row.Count
+				report.PayoutAmount += row.Paid
+			case rewardStatusHeld:
+				report.Held += row.Count
+			}
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
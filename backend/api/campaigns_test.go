@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCampaignMatches(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	storeID := primitive.NewObjectID()
+	campaign := campaignDocument{
+		Enabled:     true,
+		Prefectures: []string{"沖縄県"},
+		Categories:  []string{"ソープ"},
+		StoreIDs:    []primitive.ObjectID{storeID},
+		StartsAt:    time.Date(2026, 10, 1, 0, 0, 0, 0, jst),
+		EndsAt:      time.Date(2026, 11, 1, 0, 0, 0, 0, jst),
+	}
+	review := reviewDocument{StoreID: storeID, IndustryCode: "soap"}
+	store := storeDocument{Prefecture: "沖縄県"}
+	inside := time.Date(2026, 10, 15, 12, 0, 0, 0, jst)
+
+	cases := []struct {
+		name   string
+		mutate func(*campaignDocument, *reviewDocument, *storeDocument)
+		at     time.Time
+		want   bool
+	}{
+		{"全条件一致", func(*campaignDocument, *reviewDocument, *storeDocument) {}, inside, true},
+		{"開始時刻ちょうど", func(*campaignDocument, *reviewDocument, *storeDocument) {}, campaign.StartsAt, true},
+		{"終了時刻は含まない", func(*campaignDocument, *reviewDocument, *storeDocument) {}, campaign.EndsAt, false},
+		{"無効", func(c *campaignDocument, _ *reviewDocument, _ *storeDocument) { c.Enabled = false }, inside, false},
+		{"都道府県違い", func(_ *campaignDocument, _ *reviewDocument, s *storeDocument) { s.Prefecture = "東京都" }, inside, false},
+		{"業種違い", func(_ *campaignDocument, r *reviewDocument, _ *storeDocument) { r.IndustryCode = "デリヘル" }, inside, false},
+		{"店舗違い", func(_ *campaignDocument, r *reviewDocument, _ *storeDocument) { r.StoreID = primitive.NewObjectID() }, inside, false},
+		{"条件なしは全件対象", func(c *campaignDocument, r *reviewDocument, s *storeDocument) {
+			c.Prefectures, c.Categories, c.StoreIDs = nil, nil, nil
+			r.StoreID = primitive.NewObjectID()
+			s.Prefecture = "東京都"
+		}, inside, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, r, s := campaign, review, store
+			tc.mutate(&c, &r, &s)
+			if got := campaignMatches(c, r, s, tc.at); got != tc.want {
+				t.Errorf("campaignMatches() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseCampaignEnd(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	cases := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"日付のみは当日を含む", "2026-10-31", time.Date(2026, 11, 1, 0, 0, 0, 0, jst), false},
+		{"日時指定はそのまま", "2026-10-31T18:00:00+09:00", time.Date(2026, 10, 31, 18, 0, 0, 0, jst), false},
+		{"形式不正", "10/31", time.Time{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseCampaignEnd(tc.value, jst)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseCampaignEnd() err = %v, wantErr %t", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("parseCampaignEnd() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithCampaignUpdate(t *testing.T) {
+	campaign := &reviewCampaignDocument{Code: "okinawa", BonusAmount: 500}
+	cases := []struct {
+		name      string
+		campaign  *reviewCampaignDocument
+		rematched bool
+		want      bson.M
+	}{
+		{"再判定なし", campaign, false, bson.M{"$set": bson.M{"rating": 4}}},
+		{"再判定で一致", campaign, true, bson.M{"$set": bson.M{"rating": 4, "campaign": campaign}}},
+		{"再判定で対象外", nil, true, bson.M{"$set": bson.M{"rating": 4}, "$unset": bson.M{"campaign": ""}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := withCampaignUpdate(bson.M{"rating": 4}, tc.campaign, tc.rematched); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("withCampaignUpdate() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var errCircuitOpen = errors.New("送信先の障害が続いているため送信を一時停止しています")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+	state            circuitState
+	failures         int
+	openedAt         time.Time
+	probeInFlight    bool
+}
+
+func newCircuitBreaker(failureThreshold int, cooldown time.Duration) *circuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &circuitBreaker{failureThreshold: failureThreshold, cooldown: cooldown}
+}
+
+func (cb *circuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return errCircuitOpen
+		}
+		cb.state = circuitHalfOpen
+		cb.probeInFlight = true
+		return nil
+	case circuitHalfOpen:
+		if cb.probeInFlight {
+			return errCircuitOpen
+		}
+		cb.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (cb *circuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probeInFlight = false
+	if success {
+		cb.state = circuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.failureThreshold {
+		cb.state = circuitOpen
+		cb.openedAt = time.Now()
+	}
+}
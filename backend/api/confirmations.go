@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	rewardStatusAwaiting = "awaiting_confirmation"
+
+	rewardHoldCheckerRejected = "checker_rejected"
+
+	ledgerEventConfirmed = "confirmed"
+	ledgerEventRejected  = "rejected"
+)
+
+const (
+	adminProxySecretHeader = "X-Admin-Proxy-Secret"
+
+	adminIdentityContextKey contextKey = "adminIdentity"
+)
+
+var errRewardUnconfirmed = errors.New("謝礼は別の管理者による確認待ちです")
+
+type confirmRewardRequest struct {
+	Reject bool   `json:"reject"`
+	Note   string `json:"note"`
+}
+
+// adminIdentityMiddleware は管理画面の前段プロキシが付与した管理者IDを、共有シークレットで検証してから受け入れる。
+// ADMIN_PROXY_SECRET が未設定の場合は管理者IDを持たずに通し、謝礼の確認は受け付けない。
+func (s *server) adminIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminProxySecret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		secret := strings.TrimSpace(r.Header.Get(adminProxySecretHeader))
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(s.adminProxySecret)) != 1 {
+			s.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "管理者プロキシの認証に失敗しました"})
+			return
+		}
+		identity := strings.TrimSpace(r.Header.Get(s.adminIdentityHeader))
+		if identity == "" {
+			s.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "管理者IDがありません"})
+			return
+		}
+		ctx := context.WithValue(r.Context(), adminIdentityContextKey, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func adminIdentity(r *http.Request) (string, bool) {
+	identity, ok := r.Context().Value(adminIdentityContextKey).(string)
+	return identity, ok && identity != ""
+}
+
+// adminActor は認証済みの管理者IDを優先し、未構成の環境に限りリクエストの申告値を使う。
+func adminActor(r *http.Request, claimed string) string {
+	if identity, ok := adminIdentity(r); ok {
+		return identity
+	}
+	return strings.TrimSpace(claimed)
+}
+
+func (s *server) requireRewardConfirmation(update bson.M, actor string, now time.Time) bool {
+	if !s.rewardMakerChecker {
+		return false
+	}
+	update["reward.status"] = rewardStatusAwaiting
+	update["reward.requestedBy"] = strings.TrimSpace(actor)
+	update["reward.requestedAt"] = now
+	update["reward.confirmedBy"] = ""
+	update["reward.confirmedAt"] = nil
+	update["reward.sentAt"] = nil
+	return true
+}
+
+func (s *server) rewardConfirmed(review reviewDocument) bool {
+	if !s.rewardMakerChecker {
+		return true
+	}
+	checker := strings.TrimSpace(review.Reward.ConfirmedBy)
+	return checker != "" && checker != strings.TrimSpace(review.Reward.RequestedBy)
+}
+
+func (s *server) requestRewardConfirmation(ctx context.Context, review reviewDocument, actor string) {
+	now := time.Now().In(s.location)
+	update := bson.M{"updatedAt": now}
+	s.requireRewardConfirmation(update, actor, now)
+	if _, err := s.reviews.UpdateOne(ctx, bson.M{
+		"_id":           review.ID,
+		"reward.status": bson.M{"$in": rewardPayableStatuses},
+	}, bson.M{"$set": update}); err != nil {
+		s.logger.Printf("謝礼の確認依頼に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+}
+
+func (s *server) adminRewardAwaitingListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.reviews.Find(ctx, bson.M{"reward.status": rewardStatusAwaiting}, options.Find().SetSort(bson.D{{Key: "reward.requestedAt", Value: 1}}))
+		if err != nil {
+			s.logger.Printf("admin reward awaiting list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "確認待ちの謝礼一覧の取得に失敗しました"})
+			return
+		}
+		var reviews []reviewDocument
+		if err := cursor.All(ctx, &reviews); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "確認待ちの謝礼一覧の取得に失敗しました"})
+			return
+		}
+
+		storeIDs := make([]primitive.ObjectID, 0, len(reviews))
+		for _, review := range reviews {
+			storeIDs = append(storeIDs, review.StoreID)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		items := make([]adminReviewResponse, 0, len(reviews))
+		for _, review := range reviews {
+			items = append(items, buildAdminReviewResponse(review, stores[review.StoreID]))
+		}
+		s.writeJSON(w, http.StatusOK, adminReviewListResponse{Items: items})
+	}
+}
+
+func (s *server) adminRewardConfirmHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req confirmRewardRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		checker, ok := adminIdentity(r)
+		if !ok {
+			s.writeJSON(w, http.StatusForbidden, map[string]string{"error": "謝礼の確認には認証済みの管理者IDが必要です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(existing.Reward.Status) != rewardStatusAwaiting {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "確認待ちの謝礼ではありません"})
+			return
+		}
+		if checker == strings.TrimSpace(existing.Reward.RequestedBy) || checker == strings.TrimSpace(existing.ReviewedBy) {
+			s.writeJSON(w, http.StatusForbidden, map[string]string{"error": "承認者とは別の管理者が確認してください"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		update := bson.M{
+			"reward.confirmedBy": checker,
+			"reward.confirmedAt": now,
+			"updatedAt":          now,
+		}
+		event := ledgerEventConfirmed
+		if req.Reject {
+			event = ledgerEventRejected
+			reason := strings.TrimSpace(req.Note)
+			if reason == "" {
+				reason = "確認者により差し戻されました"
+			}
+			update["reward.status"] = rewardStatusHeld
+			update["reward.holdCode"] = rewardHoldCheckerRejected
+			update["reward.holdReason"] = reason
+			update["reward.committedAt"] = nil
+		} else {
+			update["reward.status"] = rewardStatusPending
+		}
+
+		var updated reviewDocument
+		result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": existing.ID, "reward.status": rewardStatusAwaiting}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "確認待ちの謝礼ではありません"})
+				return
+			}
+			s.logger.Printf("謝礼の確認に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼の確認に失敗しました"})
+			return
+		}
+
+		s.recordRewardLedger(ctx, rewardLedgerDocument{
+			Event:      event,
+			LinkID:     updated.Reward.LinkID,
+			ReviewID:   &updated.ID,
+			ReviewerID: updated.ReviewerID,
+			Requester:  updated.Reward.RequestedBy,
+			Confirmer:  checker,
+			Amount:     updated.Reward.Amount,
+			Actor:      checker,
+			Detail:     strings.TrimSpace(req.Note),
+		})
+		s.recordReviewAudit(ctx, updated.ID, "reward_"+event, checker, auditActorAdmin, bson.M{"requestedBy": updated.Reward.RequestedBy})
+
+		store, err := s.getStoreByID(ctx, updated.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		if !req.Reject && strings.TrimSpace(updated.Status) == "approved" {
+			go s.autoIssueReviewReward(updated)
+		}
+		s.logger.Printf("admin reward %s id=%s requestedBy=%q confirmedBy=%q", event, updated.ID.Hex(), updated.Reward.RequestedBy, checker)
+		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRequireRewardConfirmation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	off := &server{}
+	update := bson.M{"reward.status": rewardStatusPending}
+	if off.requireRewardConfirmation(update, "maker", now) {
+		t.Fatal("maker-checker無効時に確認待ちになりました")
+	}
+	if update["reward.status"] != rewardStatusPending || len(update) != 1 {
+		t.Errorf("update = %v, want unchanged", update)
+	}
+
+	on := &server{rewardMakerChecker: true}
+	update = bson.M{"reward.confirmedBy": "someone"}
+	if !on.requireRewardConfirmation(update, " maker ", now) {
+		t.Fatal("maker-checker有効時に確認待ちになりませんでした")
+	}
+	want := bson.M{
+		"reward.status":      rewardStatusAwaiting,
+		"reward.requestedBy": "maker",
+		"reward.requestedAt": now,
+		"reward.confirmedBy": "",
+		"reward.confirmedAt": nil,
+		"reward.sentAt":      nil,
+	}
+	for key, value := range want {
+		if update[key] != value {
+			t.Errorf("update[%q] = %v, want %v", key, update[key], value)
+		}
+	}
+}
+
+func TestRewardConfirmed(t *testing.T) {
+	reward := func(requestedBy, confirmedBy string) reviewDocument {
+		return reviewDocument{Reward: reviewRewardDocument{RequestedBy: requestedBy, ConfirmedBy: confirmedBy}}
+	}
+	cases := []struct {
+		name        string
+		makerCheck  bool
+		review      reviewDocument
+		wantConfirm bool
+	}{
+		{"無効時は常に可", false, reward("", ""), true},
+		{"確認者なし", true, reward("maker", ""), false},
+		{"申請者本人の確認", true, reward("maker", "maker"), false},
+		{"空白違いの本人", true, reward("maker", " maker "), false},
+		{"別の管理者", true, reward("maker", "checker"), true},
+		{"申請者不明でも確認者あり", true, reward("", "checker"), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &server{rewardMakerChecker: tc.makerCheck}
+			if got := s.rewardConfirmed(tc.review); got != tc.wantConfirm {
+				t.Errorf("rewardConfirmed() = %t, want %t", got, tc.wantConfirm)
+			}
+		})
+	}
+}
+
+func TestAdminIdentityMiddleware(t *testing.T) {
+	cases := []struct {
+		name       string
+		secret     string
+		headers    map[string]string
+		wantStatus int
+		wantActor  string
+	}{
+		{"未構成なら申告値", "", map[string]string{"X-Admin-User": "alice"}, http.StatusOK, "claimed"},
+		{"シークレット一致", "s3cret", map[string]string{adminProxySecretHeader: "s3cret", "X-Admin-User": " alice "}, http.StatusOK, "alice"},
+		{"シークレット不一致", "s3cret", map[string]string{adminProxySecretHeader: "wrong", "X-Admin-User": "alice"}, http.StatusUnauthorized, ""},
+		{"シークレットなし", "s3cret", map[string]string{"X-Admin-User": "alice"}, http.StatusUnauthorized, ""},
+		{"管理者IDなし", "s3cret", map[string]string{adminProxySecretHeader: "s3cret"}, http.StatusUnauthorized, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &server{adminProxySecret: tc.secret, adminIdentityHeader: "X-Admin-User"}
+			var actor string
+			handler := srv.adminIdentityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				actor = adminActor(r, " claimed ")
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/admin/reviews/x/reward/confirm", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if actor != tc.wantActor {
+				t.Errorf("adminActor() = %q, want %q", actor, tc.wantActor)
+			}
+		})
+	}
+}
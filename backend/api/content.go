@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	contentFindingPhone  = "phone"
+	contentFindingEmail  = "email"
+	contentFindingLineID = "line_id"
+	contentFindingURL    = "url"
+	contentFindingNGWord = "ng_word"
+
+	contentMaskModeOff  = "off"
+	contentMaskModeMask = "mask"
+
+	reviewFlagPersonalInfo = "personal_info"
+	reviewFlagNGWord       = "ng_word"
+
+	contentMaskText = "●●●"
+
+	contentRescanJobName  = "content-rescan"
+	contentPatternVersion = "1"
+)
+
+var contentFindingLabels = map[string]string{
+	contentFindingPhone:  "電話番号",
+	contentFindingEmail:  "メールアドレス",
+	contentFindingLineID: "LINE ID",
+	contentFindingURL:    "URL",
+	contentFindingNGWord: "NGワード",
+}
+
+var contentPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{contentFindingEmail, regexp.MustCompile(`[A-Za-z0-9._%+\-ａ-ｚＡ-Ｚ０-９]+[@＠][A-Za-z0-9\-ａ-ｚＡ-Ｚ０-９]+(?:[.．][A-Za-z0-9\-ａ-ｚＡ-Ｚ０-９]+)+`)},
+	{contentFindingURL, regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s　」』）)]+|[A-Za-z0-9\-]+\.(?:com|net|org|info|xyz|me|io|co\.jp|ne\.jp|jp)\b[^\s　」』）)]*`)},
+	{contentFindingLineID, regexp.MustCompile(`(?i)(?:line|ライン|ﾗｲﾝ|ＬＩＮＥ)\s*(?:id|ＩＤ|ｉｄ|アイディー)?\s*[:：=＝は]?\s*[@＠]?[A-Za-z0-9._\-]{4,20}`)},
+	{contentFindingPhone, regexp.MustCompile(`[0０][0-9０-９]{1,4}[-‐‑–—ー−－\s]?[0-9０-９]{1,4}[-‐‑–—ー−－\s]?[0-9０-９]{3,4}`)},
+}
+
+type contentFindingDocument struct {
+	Type     string `bson:"type"`
+	Match    string `bson:"match"`
+	Start    int    `bson:"start"`
+	End      int    `bson:"end"`
+	Category string `bson:"category,omitempty"`
+}
+
+type contentFinding struct {
+	Type     string `json:"type"`
+	Label    string `json:"label"`
+	Match    string `json:"match"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Category string `json:"category,omitempty"`
+}
+
+type ngWordDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Word       string             `bson:"word"`
+	Normalized string             `bson:"normalized"`
+	Category   string             `bson:"category,omitempty"`
+	CreatedBy  string             `bson:"createdBy,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+}
+
+type ngWordResponse struct {
+	ID        string    `json:"id"`
+	Word      string    `json:"word"`
+	Category  string    `json:"category,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type createNGWordRequest struct {
+	Word      string `json:"word"`
+	Category  string `json:"category"`
+	CreatedBy string `json:"createdBy"`
+}
+
+func normalizeContentRune(r rune) string {
+	if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+		return ""
+	}
+	normalized := []rune(strings.ToLower(norm.NFKC.String(string(r))))
+	for i, c := range normalized {
+		if c >= 'ァ' && c <= 'ヶ' {
+			normalized[i] = c - ('ァ' - 'ぁ')
+		}
+	}
+	return string(normalized)
+}
+
+func normalizeNGWord(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		b.WriteString(normalizeContentRune(r))
+	}
+	return b.String()
+}
+
+func normalizeWithOffsets(text string) ([]rune, []int) {
+	var normalized []rune
+	var offsets []int
+	index := 0
+	for _, r := range text {
+		for _, c := range normalizeContentRune(r) {
+			normalized = append(normalized, c)
+			offsets = append(offsets, index)
+		}
+		index++
+	}
+	return normalized, offsets
+}
+
+func runeOffset(text string, byteOffset int) int {
+	return utf8.RuneCountInString(text[:byteOffset])
+}
+
+func scanContentPatterns(text string) []contentFindingDocument {
+	var findings []contentFindingDocument
+	covered := make([][2]int, 0)
+	overlaps := func(start, end int) bool {
+		for _, span := range covered {
+			if start < span[1] && end > span[0] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, entry := range contentPatterns {
+		for _, loc := range entry.pattern.FindAllStringIndex(text, -1) {
+			start, end := runeOffset(text, loc[0]), runeOffset(text, loc[1])
+			if overlaps(start, end) {
+				continue
+			}
+			if entry.kind == contentFindingPhone && countDigits(text[loc[0]:loc[1]]) < 10 {
+				continue
+			}
+			covered = append(covered, [2]int{start, end})
+			findings = append(findings, contentFindingDocument{
+				Type:  entry.kind,
+				Match: text[loc[0]:loc[1]],
+				Start: start,
+				End:   end,
+			})
+		}
+	}
+	return findings
+}
+
+func countDigits(value string) int {
+	count := 0
+	for _, r := range value {
+		if unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count
+}
+
+func scanNGWords(text string, words []ngWordDocument) []contentFindingDocument {
+	normalized, offsets := normalizeWithOffsets(text)
+	haystack := string(normalized)
+	original := []rune(text)
+	var findings []contentFindingDocument
+	for _, word := range words {
+		needle := word.Normalized
+		if needle == "" {
+			continue
+		}
+		needleLen := utf8.RuneCountInString(needle)
+		searchFrom := 0
+		for {
+			pos := strings.Index(haystack[searchFrom:], needle)
+			if pos < 0 {
+				break
+			}
+			byteStart := searchFrom + pos
+			runeStart := utf8.RuneCountInString(haystack[:byteStart])
+			runeEnd := runeStart + needleLen - 1
+			start, end := offsets[runeStart], offsets[runeEnd]+1
+			findings = append(findings, contentFindingDocument{
+				Type:     contentFindingNGWord,
+				Match:    string(original[start:end]),
+				Start:    start,
+				End:      end,
+				Category: word.Category,
+			})
+			searchFrom = byteStart + len(needle)
+		}
+	}
+	return findings
+}
+
+func (s *server) loadNGWords(ctx context.Context) ([]ngWordDocument, error) {
+	cursor, err := s.ngWords.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "word", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	words := []ngWordDocument{}
+	if err := cursor.All(ctx, &words); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+func (s *server) scanReviewContent(ctx context.Context, comment string) []contentFindingDocument {
+	words, err := s.loadNGWords(ctx)
+	if err != nil {
+		s.logger.Printf("NGワード辞書の取得に失敗: %v", err)
+	}
+	return scanContent(comment, words)
+}
+
+func scanContent(comment string, words []ngWordDocument) []contentFindingDocument {
+	findings := scanContentPatterns(comment)
+	findings = append(findings, scanNGWords(comment, words)...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+	return findings
+}
+
+func ngWordDictionaryKey(words []ngWordDocument) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(contentPatternVersion))
+	for _, word := range words {
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(word.Normalized))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(word.Category))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))[:16]
+}
+
+func sameContentFindings(a, b []contentFindingDocument) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *server) rescanReviewContent(ctx context.Context) error {
+	words, err := s.loadNGWords(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.runBatchJob(ctx, contentRescanJobName, ngWordDictionaryKey(words), s.reviews, bson.M{}, 200, func(ctx context.Context, raw bson.Raw) error {
+		var review reviewDocument
+		if err := bson.Unmarshal(raw, &review); err != nil {
+			return err
+		}
+		findings := scanContent(review.Comment, words)
+		if sameContentFindings(findings, review.ContentFindings) {
+			return nil
+		}
+		flags := make([]reviewFlagDocument, 0, len(review.Flags))
+		for _, flag := range review.Flags {
+			if flag.Code != reviewFlagPersonalInfo && flag.Code != reviewFlagNGWord {
+				flags = append(flags, flag)
+			}
+		}
+		flags = append(flags, contentFlags(findings, time.Now().In(s.location))...)
+		_, err := s.reviews.UpdateOne(ctx,
+			bson.M{"_id": review.ID, "comment": review.Comment},
+			bson.M{"$set": bson.M{"contentFindings": findings, "flags": flags}},
+		)
+		return err
+	})
+	return err
+}
+
+func contentFlags(findings []contentFindingDocument, now time.Time) []reviewFlagDocument {
+	var flags []reviewFlagDocument
+	personal, ng := false, false
+	for _, finding := range findings {
+		if finding.Type == contentFindingNGWord {
+			ng = true
+		} else {
+			personal = true
+		}
+	}
+	if personal {
+		flags = append(flags, reviewFlagDocument{Code: reviewFlagPersonalInfo, DetectedAt: now})
+	}
+	if ng {
+		flags = append(flags, reviewFlagDocument{Code: reviewFlagNGWord, DetectedAt: now})
+	}
+	return flags
+}
+
+func maskComment(comment string, findings []contentFindingDocument) string {
+	if len(findings) == 0 {
+		return comment
+	}
+	runes := []rune(comment)
+	spans := make([][2]int, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Start < 0 || finding.End > len(runes) || finding.Start >= finding.End {
+			continue
+		}
+		if string(runes[finding.Start:finding.End]) != finding.Match {
+			continue
+		}
+		spans = append(spans, [2]int{finding.Start, finding.End})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var b strings.Builder
+	cursor := 0
+	for _, span := range spans {
+		if span[0] < cursor {
+			if span[1] > cursor {
+				cursor = span[1]
+			}
+			continue
+		}
+		b.WriteString(string(runes[cursor:span[0]]))
+		b.WriteString(contentMaskText)
+		cursor = span[1]
+	}
+	b.WriteString(string(runes[cursor:]))
+	return b.String()
+}
+
+func (s *server) publicComment(review reviewDocument) string {
+	if s.contentMaskMode != contentMaskModeMask {
+		return review.Comment
+	}
+	return maskComment(review.Comment, review.ContentFindings)
+}
+
+func buildContentFindings(findings []contentFindingDocument) []contentFinding {
+	if len(findings) == 0 {
+		return nil
+	}
+	items := make([]contentFinding, 0, len(findings))
+	for _, finding := range findings {
+		items = append(items, contentFinding{
+			Type:     finding.Type,
+			Label:    contentFindingLabels[finding.Type],
+			Match:    finding.Match,
+			Start:    finding.Start,
+			End:      finding.End,
+			Category: finding.Category,
+		})
+	}
+	return items
+}
+
+func ngWordToResponse(doc ngWordDocument) ngWordResponse {
+	return ngWordResponse{
+		ID:        doc.ID.Hex(),
+		Word:      doc.Word,
+		Category:  doc.Category,
+		CreatedBy: doc.CreatedBy,
+		CreatedAt: doc.CreatedAt,
+	}
+}
+
+func (s *server) adminNGWordListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		words, err := s.loadNGWords(ctx)
+		if err != nil {
+			s.logger.Printf("admin ng word list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "NGワードの取得に失敗しました"})
+			return
+		}
+		items := make([]ngWordResponse, 0, len(words))
+		for _, word := range words {
+			items = append(items, ngWordToResponse(word))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminNGWordCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req createNGWordRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		word := strings.TrimSpace(req.Word)
+		normalized := normalizeNGWord(word)
+		if normalized == "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "NGワードを入力してください"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := s.ngWords.FindOne(ctx, bson.M{"normalized": normalized}).Err(); err == nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "同じNGワードが既に登録されています"})
+			return
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("admin ng word lookup failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "NGワードの登録に失敗しました"})
+			return
+		}
+
+		doc := ngWordDocument{
+			ID:         primitive.NewObjectID(),
+			Word:       word,
+			Normalized: normalized,
+			Category:   strings.TrimSpace(req.Category),
+			CreatedBy:  strings.TrimSpace(req.CreatedBy),
+			CreatedAt:  time.Now().In(s.location),
+		}
+		if _, err := s.ngWords.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("admin ng word create failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "NGワードの登録に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin ng word create success id=%s category=%q", doc.ID.Hex(), doc.Category)
+		s.writeJSON(w, http.StatusCreated, ngWordToResponse(doc))
+	}
+}
+
+func (s *server) adminNGWordDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "NGワードIDの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		result, err := s.ngWords.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			s.logger.Printf("admin ng word delete failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "NGワードの削除に失敗しました"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "NGワードが見つかりません"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	correctionTypeUpdate = "update"
+	correctionTypeClosed = "closed"
+
+	correctionStatusPending  = "pending"
+	correctionStatusAccepted = "accepted"
+	correctionStatusRejected = "rejected"
+
+	maxCorrectionEvidenceRunes = 1000
+)
+
+type storeSnapshotDocument struct {
+	Name          string   `bson:"name"`
+	BranchName    string   `bson:"branchName,omitempty"`
+	Prefecture    string   `bson:"prefecture,omitempty"`
+	IndustryCodes []string `bson:"industryCodes,omitempty"`
+}
+
+type storeCorrectionDocument struct {
+	ID           primitive.ObjectID    `bson:"_id"`
+	StoreID      primitive.ObjectID    `bson:"storeId"`
+	UserID       string                `bson:"userId"`
+	Type         string                `bson:"type"`
+	StoreName    *string               `bson:"storeName,omitempty"`
+	BranchName   *string               `bson:"branchName,omitempty"`
+	Prefecture   *string               `bson:"prefecture,omitempty"`
+	IndustryCode string                `bson:"industryCode,omitempty"`
+	ClosedOn     *time.Time            `bson:"closedOn,omitempty"`
+	MovedTo      string                `bson:"movedTo,omitempty"`
+	Evidence     string                `bson:"evidence"`
+	EvidenceURL  string                `bson:"evidenceUrl,omitempty"`
+	Before       storeSnapshotDocument `bson:"before"`
+	Status       string                `bson:"status"`
+	ResolvedBy   string                `bson:"resolvedBy,omitempty"`
+	ResolvedAt   *time.Time            `bson:"resolvedAt,omitempty"`
+	ResolveNote  string                `bson:"resolveNote,omitempty"`
+	CreatedAt    time.Time             `bson:"createdAt"`
+}
+
+type storeCorrectionRequest struct {
+	Type         string  `json:"type"`
+	StoreName    *string `json:"storeName"`
+	BranchName   *string `json:"branchName"`
+	Prefecture   *string `json:"prefecture"`
+	IndustryCode string  `json:"industryCode"`
+	ClosedOn     string  `json:"closedOn"`
+	MovedTo      string  `json:"movedTo"`
+	Evidence     string  `json:"evidence"`
+	EvidenceURL  string  `json:"evidenceUrl"`
+}
+
+type resolveCorrectionRequest struct {
+	Action     string `json:"action"`
+	ResolvedBy string `json:"resolvedBy"`
+	Note       string `json:"note"`
+}
+
+type storeCorrectionResponse struct {
+	ID           string             `json:"id"`
+	StoreID      string             `json:"storeId"`
+	UserID       string             `json:"userId,omitempty"`
+	Type         string             `json:"type"`
+	StoreName    *string            `json:"storeName,omitempty"`
+	BranchName   *string            `json:"branchName,omitempty"`
+	Prefecture   *string            `json:"prefecture,omitempty"`
+	IndustryCode string             `json:"industryCode,omitempty"`
+	ClosedOn     string             `json:"closedOn,omitempty"`
+	MovedTo      string             `json:"movedTo,omitempty"`
+	Evidence     string             `json:"evidence"`
+	EvidenceURL  string             `json:"evidenceUrl,omitempty"`
+	Current      adminStoreResponse `json:"current"`
+	Before       map[string]any     `json:"before,omitempty"`
+	Status       string             `json:"status"`
+	ResolvedBy   string             `json:"resolvedBy,omitempty"`
+	ResolvedAt   *time.Time         `json:"resolvedAt,omitempty"`
+	ResolveNote  string             `json:"resolveNote,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt"`
+}
+
+func (s *server) ensureStoreCorrectionIndex(ctx context.Context) error {
+	_, err := s.storeCorrections.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "storeId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+			"status": correctionStatusPending,
+		}),
+	})
+	return err
+}
+
+func storeCorrectionToResponse(doc storeCorrectionDocument, store storeDocument, loc *time.Location) storeCorrectionResponse {
+	response := storeCorrectionResponse{
+		ID:           doc.ID.Hex(),
+		StoreID:      doc.StoreID.Hex(),
+		UserID:       doc.UserID,
+		Type:         doc.Type,
+		StoreName:    doc.StoreName,
+		BranchName:   doc.BranchName,
+		Prefecture:   doc.Prefecture,
+		IndustryCode: doc.IndustryCode,
+		MovedTo:      doc.MovedTo,
+		Evidence:     doc.Evidence,
+		EvidenceURL:  doc.EvidenceURL,
+		Current:      storeDocumentToAdminResponse(store),
+		Status:       doc.Status,
+		ResolvedBy:   doc.ResolvedBy,
+		ResolvedAt:   doc.ResolvedAt,
+		ResolveNote:  doc.ResolveNote,
+		CreatedAt:    doc.CreatedAt,
+	}
+	if doc.ClosedOn != nil {
+		response.ClosedOn = doc.ClosedOn.In(loc).Format("2006-01-02")
+	}
+	if doc.Status != correctionStatusPending {
+		response.Before = map[string]any{
+			"name":          doc.Before.Name,
+			"branchName":    doc.Before.BranchName,
+			"prefecture":    doc.Before.Prefecture,
+			"industryCodes": doc.Before.IndustryCodes,
+		}
+	}
+	return response
+}
+
+func buildStoreCorrection(req storeCorrectionRequest, store storeDocument, loc *time.Location) (storeCorrectionDocument, error) {
+	doc := storeCorrectionDocument{
+		StoreID:     store.ID,
+		Evidence:    strings.TrimSpace(req.Evidence),
+		EvidenceURL: strings.TrimSpace(req.EvidenceURL),
+	}
+	if doc.Evidence == "" {
+		return doc, errors.New("根拠となる情報を入力してください")
+	}
+	if len([]rune(doc.Evidence)) > maxCorrectionEvidenceRunes {
+		return doc, fmt.Errorf("根拠は%d文字以内で入力してください", maxCorrectionEvidenceRunes)
+	}
+	if doc.EvidenceURL != "" {
+		parsed, err := url.Parse(doc.EvidenceURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return doc, errors.New("evidenceUrlはhttpまたはhttpsのURLを指定してください")
+		}
+	}
+
+	switch strings.TrimSpace(req.Type) {
+	case "", correctionTypeUpdate:
+		doc.Type = correctionTypeUpdate
+		changed := false
+		if req.StoreName != nil {
+			name := strings.TrimSpace(*req.StoreName)
+			if name == "" {
+				return doc, errors.New("店舗名は必須です")
+			}
+			if name != store.Name {
+				doc.StoreName = &name
+				changed = true
+			}
+		}
+		if req.BranchName != nil {
+			branch := strings.TrimSpace(*req.BranchName)
+			if branch != strings.TrimSpace(store.BranchName) {
+				doc.BranchName = &branch
+				changed = true
+			}
+		}
+		if req.Prefecture != nil {
+			prefecture := strings.TrimSpace(*req.Prefecture)
+			if prefecture == "" {
+				return doc, errors.New("都道府県は必須です")
+			}
+			if prefecture != store.Prefecture {
+				doc.Prefecture = &prefecture
+				changed = true
+			}
+		}
+		if raw := strings.TrimSpace(req.IndustryCode); raw != "" {
+			industry := canonicalIndustryCode(raw)
+			if !containsString(store.IndustryCodes, industry) {
+				doc.IndustryCode = industry
+				changed = true
+			}
+		}
+		if !changed {
+			return doc, errors.New("現在の店舗情報と異なる修正内容を指定してください")
+		}
+	case correctionTypeClosed:
+		if isStoreClosed(store) {
+			return doc, errors.New("この店舗は既に閉店として登録されています")
+		}
+		doc.Type = correctionTypeClosed
+		if raw := strings.TrimSpace(req.ClosedOn); raw != "" {
+			closedOn, err := time.ParseInLocation("2006-01-02", raw, loc)
+			if err != nil {
+				return doc, errors.New("closedOnはYYYY-MM-DD形式で指定してください")
+			}
+			if closedOn.After(time.Now().In(loc)) {
+				return doc, errors.New("閉店日に未来の日付は指定できません")
+			}
+			doc.ClosedOn = &closedOn
+		}
+		doc.MovedTo = strings.TrimSpace(req.MovedTo)
+		if len([]rune(doc.MovedTo)) > 200 {
+			return doc, errors.New("移転先は200文字以内で入力してください")
+		}
+	default:
+		return doc, errors.New("typeはupdateまたはclosedを指定してください")
+	}
+	return doc, nil
+}
+
+func (s *server) storeCorrectionCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+		storeID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "店舗IDの形式が不正です"})
+			return
+		}
+		var req storeCorrectionRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		store, err := s.getStoreByID(ctx, storeID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "店舗が見つかりません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		doc, err := buildStoreCorrection(req, store, s.location)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		doc.ID = primitive.NewObjectID()
+		doc.UserID = user.ID
+		doc.Status = correctionStatusPending
+		doc.CreatedAt = time.Now().In(s.location)
+		doc.Before = storeSnapshotDocument{
+			Name:          store.Name,
+			BranchName:    store.BranchName,
+			Prefecture:    store.Prefecture,
+			IndustryCodes: store.IndustryCodes,
+		}
+		if _, err := s.storeCorrections.InsertOne(ctx, doc); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "この店舗への修正提案は確認待ちです"})
+				return
+			}
+			s.logger.Printf("店舗情報の修正提案の保存に失敗 storeId=%s err=%v", storeID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "修正提案の保存に失敗しました"})
+			return
+		}
+
+		s.logger.Printf("store correction submitted id=%s storeId=%s type=%s userId=%s", doc.ID.Hex(), storeID.Hex(), doc.Type, user.ID)
+		s.writeJSON(w, http.StatusAccepted, map[string]any{
+			"id":     doc.ID.Hex(),
+			"status": doc.Status,
+		})
+	}
+}
+
+func (s *server) adminStoreCorrectionListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		status := strings.TrimSpace(query.Get("status"))
+		if status == "" {
+			status = correctionStatusPending
+		}
+		filter := bson.M{"status": status}
+		if raw := strings.TrimSpace(query.Get("storeId")); raw != "" {
+			storeID, err := primitive.ObjectIDFromHex(raw)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "storeIdの形式が不正です"})
+				return
+			}
+			filter["storeId"] = storeID
+		}
+		if kind := strings.TrimSpace(query.Get("type")); kind != "" {
+			filter["type"] = kind
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.storeCorrections.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(200))
+		if err != nil {
+			s.logger.Printf("admin store correction list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "修正提案一覧の取得に失敗しました"})
+			return
+		}
+		var docs []storeCorrectionDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "修正提案一覧の取得に失敗しました"})
+			return
+		}
+		storeIDs := make([]primitive.ObjectID, 0, len(docs))
+		for _, doc := range docs {
+			storeIDs = append(storeIDs, doc.StoreID)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		items := make([]storeCorrectionResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, storeCorrectionToResponse(doc, stores[doc.StoreID], s.location))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminStoreCorrectionResolveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "IDの形式が不正です"})
+			return
+		}
+		var req resolveCorrectionRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		var status string
+		switch strings.TrimSpace(req.Action) {
+		case "accept":
+			status = correctionStatusAccepted
+		case "reject":
+			status = correctionStatusRejected
+		default:
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "actionはacceptまたはrejectを指定してください"})
+			return
+		}
+		resolvedBy := strings.TrimSpace(req.ResolvedBy)
+		note := strings.TrimSpace(req.Note)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		var correction storeCorrectionDocument
+		if err := s.storeCorrections.FindOne(ctx, bson.M{"_id": objectID}).Decode(&correction); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "修正提案が見つかりません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "修正提案の取得に失敗しました"})
+			return
+		}
+		if correction.Status != correctionStatusPending {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "この修正提案は既に処理済みです"})
+			return
+		}
+		store, err := s.getStoreByID(ctx, correction.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		var updated storeCorrectionDocument
+		result := s.storeCorrections.FindOneAndUpdate(ctx,
+			bson.M{"_id": objectID, "status": correctionStatusPending},
+			bson.M{"$set": bson.M{
+				"status":      status,
+				"resolvedBy":  resolvedBy,
+				"resolvedAt":  now,
+				"resolveNote": note,
+				"before": storeSnapshotDocument{
+					Name:          store.Name,
+					BranchName:    store.BranchName,
+					Prefecture:    store.Prefecture,
+					IndustryCodes: store.IndustryCodes,
+				},
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		)
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "この修正提案は既に処理済みです"})
+				return
+			}
+			s.logger.Printf("修正提案の更新に失敗 id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "修正提案の更新に失敗しました"})
+			return
+		}
+
+		if status == correctionStatusAccepted {
+			switch correction.Type {
+			case correctionTypeClosed:
+				closedAt := now
+				if correction.ClosedOn != nil {
+					closedAt = *correction.ClosedOn
+				}
+				statusNote := note
+				if statusNote == "" && correction.MovedTo != "" {
+					statusNote = "移転先: " + correction.MovedTo
+				}
+				err = s.markStoreClosed(ctx, store.ID, closedAt, statusNote, now)
+			default:
+				storeUpdate := bson.M{}
+				if correction.StoreName != nil && *correction.StoreName != store.Name {
+					for key, value := range storeRenameFields(store, *correction.StoreName) {
+						storeUpdate[key] = value
+					}
+				}
+				if correction.BranchName != nil {
+					storeUpdate["branchName"] = *correction.BranchName
+				}
+				if correction.Prefecture != nil {
+					storeUpdate["prefecture"] = *correction.Prefecture
+				}
+				err = s.applyStoreUpdate(ctx, store.ID, storeUpdate, correction.IndustryCode, now)
+			}
+			if err != nil {
+				s.logger.Printf("修正提案の反映に失敗 id=%s storeId=%s err=%v", objectID.Hex(), store.ID.Hex(), err)
+				if _, revertErr := s.storeCorrections.UpdateOne(ctx, bson.M{"_id": objectID, "status": status}, bson.M{
+					"$set":   bson.M{"status": correctionStatusPending},
+					"$unset": bson.M{"resolvedBy": "", "resolvedAt": "", "resolveNote": "", "before": ""},
+				}); revertErr != nil {
+					s.logger.Printf("修正提案の差し戻しに失敗 id=%s err=%v", objectID.Hex(), revertErr)
+				}
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の更新に失敗しました"})
+				return
+			}
+		}
+
+		if status == correctionStatusAccepted {
+			if refreshed, err := s.getStoreByID(ctx, store.ID); err == nil {
+				store = refreshed
+			}
+		}
+		go s.notifyReviewer(context.Background(), updated.UserID, notification{
+			Subject:        "店舗情報の修正提案について",
+			Text:           buildCorrectionResultMessage(updated, store),
+			IdempotencyKey: "store-correction:" + updated.ID.Hex(),
+		})
+		s.logger.Printf("admin store correction %s id=%s storeId=%s by=%q", status, updated.ID.Hex(), store.ID.Hex(), resolvedBy)
+		s.writeJSON(w, http.StatusOK, storeCorrectionToResponse(updated, store, s.location))
+	}
+}
+
+func buildCorrectionResultMessage(correction storeCorrectionDocument, store storeDocument) string {
+	lines := []string{}
+	if correction.Status == correctionStatusAccepted {
+		lines = append(lines, storeLabel(store)+"の店舗情報について、ご提案いただいた修正を反映しました。ご協力ありがとうございます！")
+	} else {
+		lines = append(lines, storeLabel(store)+"の店舗情報について、ご提案いただいた修正は確認の結果見送りとなりました。")
+		if correction.ResolveNote != "" {
+			lines = append(lines, "", "**理由**", "> "+correction.ResolveNote)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	reviewFlagSamePeriodOtherStore = "same_period_other_store"
+	reviewFlagSimilarComment       = "similar_comment"
+	reviewFlagRapidSubmission      = "rapid_submission"
+
+	similarCommentThreshold = 0.8
+	similarCommentMinRunes  = 20
+	rapidSubmissionWindow   = 24 * time.Hour
+	rapidSubmissionLimit    = 3
+	duplicateCandidateLimit = 50
+)
+
+var reviewFlagLabels = map[string]string{
+	reviewFlagSamePeriodOtherStore: "同じ時期に別店舗のアンケートを投稿しています",
+	reviewFlagSimilarComment:       "過去の投稿と感想が酷似しています",
+	reviewFlagRapidSubmission:      "短時間に複数のアンケートを投稿しています",
+	reviewFlagCopiedComment:        "別アカウントの投稿と感想がほぼ同一です",
+	reviewFlagPersonalInfo:         "感想に個人情報が含まれている可能性があります",
+	reviewFlagNGWord:               "感想にNGワードが含まれています",
+}
+
+var errDuplicateReview = errors.New("同じ店舗・同じ時期のアンケートは既に投稿されています")
+
+var activeReviewStatuses = []string{"pending", "approved", reviewStatusNeedsChanges, reviewStatusOnHold, reviewStatusUnpublished}
+
+type reviewFlagDocument struct {
+	Code            string             `bson:"code"`
+	RelatedReviewID primitive.ObjectID `bson:"relatedReviewId,omitempty"`
+	Score           float64            `bson:"score,omitempty"`
+	DetectedAt      time.Time          `bson:"detectedAt"`
+}
+
+type reviewWarningResponse struct {
+	Code            string  `json:"code"`
+	Label           string  `json:"label"`
+	RelatedReviewID string  `json:"relatedReviewId,omitempty"`
+	Score           float64 `json:"score,omitempty"`
+}
+
+func buildReviewWarnings(flags []reviewFlagDocument) []reviewWarningResponse {
+	if len(flags) == 0 {
+		return nil
+	}
+	warnings := make([]reviewWarningResponse, 0, len(flags))
+	for _, flag := range flags {
+		label, ok := reviewFlagLabels[flag.Code]
+		if !ok {
+			label = flag.Code
+		}
+		warnings = append(warnings, reviewWarningResponse{
+			Code:            flag.Code,
+			Label:           label,
+			RelatedReviewID: objectIDHex(flag.RelatedReviewID),
+			Score:           flag.Score,
+		})
+	}
+	return warnings
+}
+
+// reviewerId を持たない取り込みレビューは null 同士で衝突するため、部分インデックスの対象から外す。
+// 旧定義のインデックスは同名で作り直せないため先に削除する。
+func (s *server) ensureReviewDedupeIndex(ctx context.Context) error {
+	_, _ = s.reviews.Indexes().DropOne(ctx, "reviewer_store_period_active")
+	_, err := s.reviews.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "reviewerId", Value: 1}, {Key: "storeId", Value: 1}, {Key: "period", Value: 1}},
+		Options: options.Index().SetName("reviewer_store_period_active_v2").SetUnique(true).SetPartialFilterExpression(bson.M{
+			"status":     bson.M{"$in": activeReviewStatuses},
+			"reviewerId": bson.M{"$type": "string", "$gt": ""},
+		}),
+	})
+	return err
+}
+
+func (s *server) ensureNotDuplicateReview(ctx context.Context, reviewerID string, storeID primitive.ObjectID, period string, excludeID primitive.ObjectID) error {
+	filter := bson.M{
+		"reviewerId": reviewerID,
+		"storeId":    storeID,
+		"period":     period,
+		"status":     bson.M{"$in": activeReviewStatuses},
+	}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+	err := s.reviews.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err == nil {
+		return errDuplicateReview
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+func (s *server) detectReviewFlags(ctx context.Context, review reviewDocument) ([]reviewFlagDocument, error) {
+	filter := bson.M{
+		"reviewerId": review.ReviewerID,
+		"status":     bson.M{"$ne": reviewStatusWithdrawn},
+	}
+	if !review.ID.IsZero() {
+		filter["_id"] = bson.M{"$ne": review.ID}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(duplicateCandidateLimit)
+	cursor, err := s.reviews.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []reviewDocument
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.location)
+	flags := []reviewFlagDocument{}
+	recent := 0
+	samePeriodFlagged := false
+	similarFlagged := false
+	for _, candidate := range candidates {
+		if !samePeriodFlagged && candidate.Period == review.Period && candidate.StoreID != review.StoreID {
+			flags = append(flags, reviewFlagDocument{Code: reviewFlagSamePeriodOtherStore, RelatedReviewID: candidate.ID, DetectedAt: now})
+			samePeriodFlagged = true
+		}
+		if !similarFlagged {
+			if score := commentSimilarity(candidate.Comment, review.Comment); score >= similarCommentThreshold {
+				flags = append(flags, reviewFlagDocument{Code: reviewFlagSimilarComment, RelatedReviewID: candidate.ID, Score: math.Round(score*100) / 100, DetectedAt: now})
+				similarFlagged = true
+			}
+		}
+		if now.Sub(candidate.CreatedAt) <= rapidSubmissionWindow {
+			recent++
+		}
+	}
+	if recent+1 >= rapidSubmissionLimit {
+		flags = append(flags, reviewFlagDocument{Code: reviewFlagRapidSubmission, Score: float64(recent + 1), DetectedAt: now})
+	}
+
+	copied, err := s.findCopiedComment(ctx, review)
+	if err != nil {
+		return flags, err
+	}
+	if copied != nil {
+		flags = append(flags, *copied)
+	}
+	return flags, nil
+}
+
+func normalizeComment(comment string) []rune {
+	normalized := make([]rune, 0, len(comment))
+	for _, r := range norm.NFKC.String(strings.ToLower(comment)) {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 'ァ' - 'ぁ'
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized
+}
+
+func commentBigrams(runes []rune) map[string]struct{} {
+	grams := make(map[string]struct{}, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])] = struct{}{}
+	}
+	return grams
+}
+
+func commentSimilarity(a, b string) float64 {
+	left := normalizeComment(a)
+	right := normalizeComment(b)
+	if len(left) < similarCommentMinRunes || len(right) < similarCommentMinRunes {
+		return 0
+	}
+	leftGrams := commentBigrams(left)
+	rightGrams := commentBigrams(right)
+	intersection := 0
+	for gram := range leftGrams {
+		if _, ok := rightGrams[gram]; ok {
+			intersection++
+		}
+	}
+	union := len(leftGrams) + len(rightGrams) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
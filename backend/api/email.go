@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	smtpTLSRequired      = "required"
+	smtpTLSOpportunistic = "opportunistic"
+	smtpTLSDisabled      = "disabled"
+
+	emailConfirmTokenTTL = 24 * time.Hour
+)
+
+type smtpConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	tlsMode  string
+	timeout  time.Duration
+}
+
+type emailNotifier struct {
+	cfg      smtpConfig
+	fromAddr *mail.Address
+}
+
+func newEmailNotifier(cfg smtpConfig) (*emailNotifier, error) {
+	from, err := mail.ParseAddress(cfg.from)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP_FROM の形式が不正です: %w", err)
+	}
+	if cfg.port <= 0 {
+		cfg.port = 587
+	}
+	if cfg.timeout <= 0 {
+		cfg.timeout = 10 * time.Second
+	}
+	switch cfg.tlsMode {
+	case smtpTLSRequired, smtpTLSOpportunistic, smtpTLSDisabled:
+	default:
+		cfg.tlsMode = smtpTLSRequired
+	}
+	return &emailNotifier{cfg: cfg, fromAddr: from}, nil
+}
+
+func (n *emailNotifier) send(ctx context.Context, message notification) error {
+	to, err := mail.ParseAddress(strings.TrimSpace(message.Recipient))
+	if err != nil {
+		return fmt.Errorf("メール送信先の形式が不正です: %w", err)
+	}
+	text := strings.TrimSpace(message.Text)
+	if text == "" {
+		return errors.New("メール送信本文が空です")
+	}
+	subject := strings.TrimSpace(message.Subject)
+	if subject == "" {
+		subject = "まことクラブからのお知らせ"
+	}
+
+	body, err := n.buildMessage(to, subject, text, renderNotificationHTML(text))
+	if err != nil {
+		return err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.timeout)
+	defer cancel()
+
+	return n.deliver(ctx, to.Address, body)
+}
+
+func (n *emailNotifier) buildMessage(to *mail.Address, subject, text, htmlBody string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + n.fromAddr.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + n.messageID(),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", writer.Boundary()),
+	}
+	var message bytes.Buffer
+	message.WriteString(strings.Join(headers, "\r\n"))
+	message.WriteString("\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", htmlBody},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("メール本文の作成に失敗: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(part.content, "\n", "\r\n"))); err != nil {
+			return nil, fmt.Errorf("メール本文の作成に失敗: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("メール本文の作成に失敗: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("メール本文の作成に失敗: %w", err)
+	}
+
+	message.Write(buf.Bytes())
+	return message.Bytes(), nil
+}
+
+func (n *emailNotifier) messageID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	domain := "makoto-club"
+	if at := strings.LastIndex(n.fromAddr.Address, "@"); at >= 0 {
+		domain = n.fromAddr.Address[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
+
+func (n *emailNotifier) deliver(ctx context.Context, to string, body []byte) error {
+	addr := net.JoinHostPort(n.cfg.host, strconv.Itoa(n.cfg.port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP サーバーへの接続に失敗: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP セッションの開始に失敗: %w", err)
+	}
+	defer client.Close()
+
+	if n.cfg.tlsMode != smtpTLSDisabled {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.cfg.host, MinVersion: tls.VersionTLS12}); err != nil {
+				return fmt.Errorf("STARTTLS に失敗: %w", err)
+			}
+		} else if n.cfg.tlsMode == smtpTLSRequired {
+			return errors.New("SMTP サーバーが STARTTLS に対応していません")
+		}
+	}
+
+	if n.cfg.username != "" {
+		auth := smtp.PlainAuth("", n.cfg.username, n.cfg.password, n.cfg.host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP 認証に失敗: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.fromAddr.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL コマンドに失敗: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP RCPT コマンドに失敗: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA コマンドに失敗: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return fmt.Errorf("メール本文の送信に失敗: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("メール本文の送信に失敗: %w", err)
+	}
+	return client.Quit()
+}
+
+var (
+	markdownBoldPattern = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+func renderNotificationHTML(text string) string {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html lang="ja"><head><meta charset="UTF-8"></head>`)
+	b.WriteString(`<body style="font-family:sans-serif;line-height:1.6;color:#333;">`)
+
+	inQuote := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		quoted := strings.HasPrefix(trimmed, ">")
+		if quoted && !inQuote {
+			b.WriteString(`<blockquote style="margin:0 0 8px;padding-left:12px;border-left:3px solid #e91e63;">`)
+			inQuote = true
+		} else if !quoted && inQuote {
+			b.WriteString("</blockquote>")
+			inQuote = false
+		}
+		if quoted {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+		}
+		if trimmed == "" {
+			if !inQuote {
+				b.WriteString("<br>")
+			}
+			continue
+		}
+
+		escaped := html.EscapeString(trimmed)
+		escaped = markdownBoldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = markdownLinkPattern.ReplaceAllString(escaped, `<a href="$2">$1</a>`)
+		b.WriteString("<div>")
+		b.WriteString(escaped)
+		b.WriteString("</div>")
+	}
+	if inQuote {
+		b.WriteString("</blockquote>")
+	}
+
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+type userEmailDocument struct {
+	UserID         string     `bson:"_id"`
+	Email          string     `bson:"email,omitempty"`
+	VerifiedAt     *time.Time `bson:"verifiedAt,omitempty"`
+	PendingEmail   string     `bson:"pendingEmail,omitempty"`
+	TokenHash      string     `bson:"tokenHash,omitempty"`
+	TokenExpiresAt *time.Time `bson:"tokenExpiresAt,omitempty"`
+	UpdatedAt      time.Time  `bson:"updatedAt"`
+}
+
+type emailConfirmSendDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    string             `bson:"userId"`
+	Email     string             `bson:"email"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+type userEmailResponse struct {
+	Email        string     `json:"email,omitempty"`
+	Verified     bool       `json:"verified"`
+	VerifiedAt   *time.Time `json:"verifiedAt,omitempty"`
+	PendingEmail string     `json:"pendingEmail,omitempty"`
+}
+
+type updateUserEmailRequest struct {
+	Email string `json:"email"`
+}
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *server) ensureEmailSendIndexes(ctx context.Context) error {
+	_, err := s.emailSends.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(s.emailRateWindow.Seconds())),
+		},
+	})
+	return err
+}
+
+func (s *server) emailConfirmRetryAfter(ctx context.Context, userID, address string, now time.Time) (time.Duration, error) {
+	var last emailConfirmSendDocument
+	err := s.emailSends.FindOne(ctx,
+		bson.M{
+			"$or":       bson.A{bson.M{"userId": userID}, bson.M{"email": address}},
+			"createdAt": bson.M{"$gt": now.Add(-s.emailCooldown)},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	).Decode(&last)
+	if err == nil {
+		return s.emailCooldown - now.Sub(last.CreatedAt), nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+
+	since := now.Add(-s.emailRateWindow)
+	byUser, err := s.emailSends.CountDocuments(ctx, bson.M{"userId": userID, "createdAt": bson.M{"$gte": since}})
+	if err != nil {
+		return 0, err
+	}
+	if byUser >= int64(s.emailUserLimit) {
+		return s.emailRateWindow, nil
+	}
+	byAddress, err := s.emailSends.CountDocuments(ctx, bson.M{"email": address, "createdAt": bson.M{"$gte": since}})
+	if err != nil {
+		return 0, err
+	}
+	if byAddress >= int64(s.emailAddrLimit) {
+		return s.emailRateWindow, nil
+	}
+	return 0, nil
+}
+
+func userEmailToResponse(doc userEmailDocument) userEmailResponse {
+	return userEmailResponse{
+		Email:        doc.Email,
+		Verified:     doc.Email != "" && doc.VerifiedAt != nil,
+		VerifiedAt:   doc.VerifiedAt,
+		PendingEmail: doc.PendingEmail,
+	}
+}
+
+func (s *server) verifiedEmail(ctx context.Context, userID string) string {
+	userID = strings.TrimSpace(userID)
+	if userID == "" || s.userEmails == nil {
+		return ""
+	}
+	var doc userEmailDocument
+	if err := s.userEmails.FindOne(ctx, bson.M{"_id": userID}).Decode(&doc); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("メールアドレスの取得に失敗 userId=%s err=%v", userID, err)
+		}
+		return ""
+	}
+	if doc.VerifiedAt == nil {
+		return ""
+	}
+	return doc.Email
+}
+
+// sendReviewerEmail はメール未設定・未認証のユーザーには送らず sent=false を返す。
+func (s *server) sendReviewerEmail(ctx context.Context, userID string, message notification) (bool, error) {
+	if s.emailNotifier == nil {
+		return false, nil
+	}
+	address := s.verifiedEmail(ctx, userID)
+	if address == "" {
+		return false, nil
+	}
+	message.Recipient = address
+	if err := s.emailNotifier.send(ctx, message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *server) userEmailGetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var doc userEmailDocument
+		if err := s.userEmails.FindOne(ctx, bson.M{"_id": user.ID}).Decode(&doc); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("メールアドレスの取得に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの取得に失敗しました"})
+			return
+		}
+
+		s.writeJSON(w, http.StatusOK, userEmailToResponse(doc))
+	}
+}
+
+func (s *server) userEmailUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+		if s.emailNotifier == nil || s.emailConfirmURL == "" {
+			s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "メール通知は現在利用できません"})
+			return
+		}
+
+		var req updateUserEmailRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		parsed, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+		if err != nil || parsed.Name != "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "メールアドレスの形式が不正です"})
+			return
+		}
+		address := strings.ToLower(parsed.Address)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		retryAfter, err := s.emailConfirmRetryAfter(ctx, user.ID, address, now)
+		if err != nil {
+			s.logger.Printf("確認メールの送信回数の確認に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの登録に失敗しました"})
+			return
+		}
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			s.writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "確認メールの送信回数が多すぎます。しばらくしてから再度お試しください"})
+			return
+		}
+
+		tokenBytes := make([]byte, 32)
+		if _, err := rand.Read(tokenBytes); err != nil {
+			s.logger.Printf("メール確認トークンの生成に失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの登録に失敗しました"})
+			return
+		}
+		token := hex.EncodeToString(tokenBytes)
+		expiresAt := now.Add(emailConfirmTokenTTL)
+
+		update := bson.M{"$set": bson.M{
+			"pendingEmail":   address,
+			"tokenHash":      hashEmailToken(token),
+			"tokenExpiresAt": expiresAt,
+			"updatedAt":      now,
+		}}
+		var doc userEmailDocument
+		result := s.userEmails.FindOneAndUpdate(ctx, bson.M{"_id": user.ID}, update, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+		if err := result.Decode(&doc); err != nil {
+			s.logger.Printf("メールアドレスの保存に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの登録に失敗しました"})
+			return
+		}
+
+		if _, err := s.emailSends.InsertOne(ctx, emailConfirmSendDocument{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			Email:     address,
+			CreatedAt: now,
+		}); err != nil {
+			s.logger.Printf("確認メールの送信記録に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの登録に失敗しました"})
+			return
+		}
+
+		link := s.emailConfirmURL
+		separator := "?"
+		if strings.Contains(link, "?") {
+			separator = "&"
+		}
+		link = link + separator + "token=" + url.QueryEscape(token)
+
+		message := strings.Join([]string{
+			"メールアドレスの確認をお願いします。",
+			"",
+			"以下のリンクを開くと、アンケートの受付・承認のお知らせがこのアドレスに届くようになります。",
+			fmt.Sprintf("[メールアドレスを確認する](%s)", link),
+			"",
+			fmt.Sprintf("リンクの有効期限は %s までです。", expiresAt.Format("2006-01-02 15:04")),
+			"お心当たりがない場合はこのメールを破棄してください。",
+		}, "\n")
+		if err := s.emailNotifier.send(ctx, notification{Recipient: address, Subject: "メールアドレスの確認", Text: message}); err != nil {
+			s.logger.Printf("確認メールの送信に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusBadGateway, map[string]string{"error": "確認メールの送信に失敗しました"})
+			return
+		}
+
+		s.writeJSON(w, http.StatusAccepted, userEmailToResponse(doc))
+	}
+}
+
+func (s *server) userEmailConfirmHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSpace(r.URL.Query().Get("token"))
+		if token == "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "確認トークンが指定されていません"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		var doc userEmailDocument
+		err := s.userEmails.FindOne(ctx, bson.M{
+			"tokenHash":      hashEmailToken(token),
+			"tokenExpiresAt": bson.M{"$gt": now},
+		}).Decode(&doc)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "確認リンクが無効か期限切れです"})
+				return
+			}
+			s.logger.Printf("メール確認トークンの照合に失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの確認に失敗しました"})
+			return
+		}
+
+		update := bson.M{
+			"$set":   bson.M{"email": doc.PendingEmail, "verifiedAt": now, "updatedAt": now},
+			"$unset": bson.M{"pendingEmail": "", "tokenHash": "", "tokenExpiresAt": ""},
+		}
+		if _, err := s.userEmails.UpdateByID(ctx, doc.UserID, update); err != nil {
+			s.logger.Printf("メールアドレスの確認結果の保存に失敗 userId=%s err=%v", doc.UserID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの確認に失敗しました"})
+			return
+		}
+
+		if s.emailRedirectURL != "" {
+			http.Redirect(w, r, s.emailRedirectURL, http.StatusSeeOther)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "email": doc.PendingEmail})
+	}
+}
+
+func (s *server) userEmailDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if _, err := s.userEmails.DeleteOne(ctx, bson.M{"_id": user.ID}); err != nil {
+			s.logger.Printf("メールアドレスの削除に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "メールアドレスの削除に失敗しました"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const reviewStatusNeedsChanges = "needs_changes"
+
+type feedbackReason struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+}
+
+var feedbackReasons = []feedbackReason{
+	{Code: "store_info", Label: "店舗名・支店名・都道府県に誤りがあります"},
+	{Code: "visited_at", Label: "働いた時期を確認してください"},
+	{Code: "values_unclear", Label: "稼ぎ・待機時間などの数値を見直してください"},
+	{Code: "comment_insufficient", Label: "感想の内容をもう少し詳しく書いてください"},
+	{Code: "personal_info", Label: "個人を特定できる情報を削除してください"},
+	{Code: "inappropriate", Label: "不適切な表現が含まれています"},
+	{Code: "other", Label: "その他"},
+}
+
+type reviewFeedbackDocument struct {
+	Reasons     []string  `bson:"reasons,omitempty"`
+	Message     string    `bson:"message,omitempty"`
+	RequestedBy string    `bson:"requestedBy,omitempty"`
+	RequestedAt time.Time `bson:"requestedAt"`
+}
+
+type reviewFeedbackResponse struct {
+	Reasons     []feedbackReason `json:"reasons"`
+	Message     string           `json:"message,omitempty"`
+	RequestedAt time.Time        `json:"requestedAt"`
+}
+
+func feedbackReasonLabel(code string) (string, bool) {
+	for _, reason := range feedbackReasons {
+		if reason.Code == code {
+			return reason.Label, true
+		}
+	}
+	return "", false
+}
+
+func buildReviewFeedback(codes []string, message, requestedBy string, now time.Time) (*reviewFeedbackDocument, error) {
+	reasons := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if _, ok := feedbackReasonLabel(code); !ok {
+			return nil, fmt.Errorf("未対応の差し戻し理由です: %s", code)
+		}
+		if !contains(reasons, code) {
+			reasons = append(reasons, code)
+		}
+	}
+	message = strings.TrimSpace(message)
+	if len(reasons) == 0 && message == "" {
+		return nil, errors.New("差し戻し理由かメッセージを指定してください")
+	}
+	if len([]rune(message)) > 1000 {
+		return nil, errors.New("差し戻しメッセージは1000文字以内で入力してください")
+	}
+	return &reviewFeedbackDocument{
+		Reasons:     reasons,
+		Message:     message,
+		RequestedBy: strings.TrimSpace(requestedBy),
+		RequestedAt: now,
+	}, nil
+}
+
+func feedbackToResponse(doc *reviewFeedbackDocument) *reviewFeedbackResponse {
+	if doc == nil {
+		return nil
+	}
+	reasons := make([]feedbackReason, 0, len(doc.Reasons))
+	for _, code := range doc.Reasons {
+		label, ok := feedbackReasonLabel(code)
+		if !ok {
+			label = code
+		}
+		reasons = append(reasons, feedbackReason{Code: code, Label: label})
+	}
+	return &reviewFeedbackResponse{
+		Reasons:     reasons,
+		Message:     doc.Message,
+		RequestedAt: doc.RequestedAt,
+	}
+}
+
+func buildNeedsChangesMessage(summary reviewSummaryResponse, feedback *reviewFeedbackDocument) string {
+	lines := []string{
+		"アンケートの内容について修正のお願いがあります。",
+		"",
+		fmt.Sprintf("**店舗名**\n> %s", summary.StoreName),
+		"",
+	}
+	if feedback != nil {
+		if len(feedback.Reasons) > 0 {
+			lines = append(lines, "**修正が必要な点**")
+			for _, code := range feedback.Reasons {
+				label, _ := feedbackReasonLabel(code)
+				lines = append(lines, "> ・"+label)
+			}
+			lines = append(lines, "")
+		}
+		if feedback.Message != "" {
+			lines = append(lines, "**運営からのメッセージ**", "> "+feedback.Message, "")
+		}
+	}
+	lines = append(lines, "マイページから内容を修正して再提出いただくと、改めて確認のうえPayPayのリンクをお送りします。")
+	return strings.Join(lines, "\n")
+}
+
+func (s *server) notifyReviewNeedsChanges(ctx context.Context, review reviewDocument, store storeDocument) {
+	summary := s.buildReviewSummary(review, store)
+	s.notifyReviewer(ctx, review.ReviewerID, notification{
+		Subject:        "アンケートの修正のお願い",
+		Text:           buildNeedsChangesMessage(summary, review.Feedback),
+		IdempotencyKey: fmt.Sprintf("review-needs-changes:%s:%d", summary.ID, review.Revision),
+	})
+}
+
+func (s *server) adminFeedbackReasonListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": feedbackReasons})
+	}
+}
+
+func (s *server) myReviewResubmitHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		user, existing, ok := s.findOwnReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(existing.Status) != reviewStatusNeedsChanges {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "修正依頼中のレビューのみ再提出できます"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		updated, store, ok := s.applyReviewerContent(ctx, w, r, user, existing, "resubmission", bson.M{
+			"status":          "pending",
+			"resubmittedAt":   now,
+			"reviewedAt":      nil,
+			"slaReminderTier": 0,
+		})
+		if !ok {
+			return
+		}
+
+		s.recordReviewAudit(ctx, updated.ID, "reviewer_resubmit", user.ID, auditActorReviewer, bson.M{
+			"revision": updated.Revision,
+		})
+
+		updated = s.applyModerationRules(ctx, updated, store)
+		if reviewAwaitsModerator(updated) {
+			summary := s.buildReviewSummary(updated, store)
+			go s.notifyModerators(context.Background(), user, summary, updated.Comment, updated.Revision)
+		}
+
+		s.logger.Printf("reviewer review resubmit success id=%s userId=%s revision=%d", updated.ID.Hex(), user.ID, updated.Revision)
+		s.writeJSON(w, http.StatusOK, s.buildMyReviewResponse(updated, store))
+	}
+}
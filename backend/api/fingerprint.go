@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	reviewFlagCopiedComment = "copied_comment"
+
+	minHashSize        = 64
+	minHashBands       = 16
+	minHashRowsPerBand = minHashSize / minHashBands
+	commentShingleSize = 3
+	similarMatchLimit  = 20
+
+	fingerprintBackfillJobName = "comment-fingerprint-backfill"
+	fingerprintBackfillRunKey  = "v1"
+)
+
+type commentFingerprintDocument struct {
+	ReviewID   primitive.ObjectID `bson:"_id"`
+	ReviewerID string             `bson:"reviewerId"`
+	StoreID    primitive.ObjectID `bson:"storeId"`
+	MinHash    []int64            `bson:"minHash"`
+	Bands      []string           `bson:"bands"`
+	UpdatedAt  time.Time          `bson:"updatedAt"`
+}
+
+type commentFingerprint struct {
+	MinHash []int64
+	Bands   []string
+}
+
+type similarReviewPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+type similarClusterReview struct {
+	ReviewID   string    `json:"reviewId"`
+	ReviewerID string    `json:"reviewerId"`
+	StoreID    string    `json:"storeId"`
+	StoreName  string    `json:"storeName"`
+	Status     string    `json:"status"`
+	Excerpt    string    `json:"excerpt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type similarClusterResponse struct {
+	Size          int                    `json:"size"`
+	MaxSimilarity float64                `json:"maxSimilarity"`
+	ReviewerIDs   []string               `json:"reviewerIds"`
+	Reviews       []similarClusterReview `json:"reviews"`
+	Pairs         []similarReviewPair    `json:"pairs"`
+}
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func computeCommentFingerprint(comment string) (commentFingerprint, bool) {
+	runes := normalizeComment(comment)
+	if len(runes) < similarCommentMinRunes {
+		return commentFingerprint{}, false
+	}
+
+	signature := make([]uint64, minHashSize)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for i := 0; i+commentShingleSize <= len(runes); i++ {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(string(runes[i : i+commentShingleSize])))
+		base := hasher.Sum64()
+		for j := range signature {
+			if value := splitMix64(base ^ uint64(j)*0x9e3779b97f4a7c15); value < signature[j] {
+				signature[j] = value
+			}
+		}
+	}
+
+	fp := commentFingerprint{
+		MinHash: make([]int64, minHashSize),
+		Bands:   make([]string, 0, minHashBands),
+	}
+	for i, value := range signature {
+		fp.MinHash[i] = int64(value)
+	}
+	for band := 0; band < minHashBands; band++ {
+		hasher := fnv.New64a()
+		for _, value := range signature[band*minHashRowsPerBand : (band+1)*minHashRowsPerBand] {
+			hasher.Write([]byte(strconv.FormatUint(value, 16)))
+		}
+		fp.Bands = append(fp.Bands, fmt.Sprintf("%02d:%x", band, hasher.Sum64()))
+	}
+	return fp, true
+}
+
+func minHashSimilarity(a, b []int64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return math.Round(float64(same)/float64(len(a))*100) / 100
+}
+
+func (s *server) ensureCommentFingerprintIndex(ctx context.Context) error {
+	_, err := s.commentFingerprints.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "bands", Value: 1}}})
+	return err
+}
+
+func isReviewFingerprinted(review reviewDocument) bool {
+	if review.DeletedAt != nil {
+		return false
+	}
+	switch strings.TrimSpace(review.Status) {
+	case "rejected", reviewStatusWithdrawn:
+		return false
+	}
+	return true
+}
+
+func (s *server) indexReviewComment(ctx context.Context, review reviewDocument) {
+	if err := s.syncCommentFingerprint(ctx, review); err != nil {
+		s.logger.Printf("コメント指紋の更新に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+}
+
+func (s *server) syncCommentFingerprint(ctx context.Context, review reviewDocument) error {
+	fp, ok := computeCommentFingerprint(review.Comment)
+	if !ok || !isReviewFingerprinted(review) {
+		_, err := s.commentFingerprints.DeleteOne(ctx, bson.M{"_id": review.ID})
+		return err
+	}
+	doc := commentFingerprintDocument{
+		ReviewID:   review.ID,
+		ReviewerID: review.ReviewerID,
+		StoreID:    review.StoreID,
+		MinHash:    fp.MinHash,
+		Bands:      fp.Bands,
+		UpdatedAt:  time.Now().In(s.location),
+	}
+	_, err := s.commentFingerprints.ReplaceOne(ctx, bson.M{"_id": review.ID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *server) backfillCommentFingerprints(ctx context.Context) error {
+	_, err := s.runBatchJob(ctx, fingerprintBackfillJobName, fingerprintBackfillRunKey, s.reviews, bson.M{}, 200, func(ctx context.Context, raw bson.Raw) error {
+		var review reviewDocument
+		if err := bson.Unmarshal(raw, &review); err != nil {
+			return err
+		}
+		return s.syncCommentFingerprint(ctx, review)
+	})
+	return err
+}
+
+func (s *server) findCopiedComment(ctx context.Context, review reviewDocument) (*reviewFlagDocument, error) {
+	fp, ok := computeCommentFingerprint(review.Comment)
+	if !ok {
+		return nil, nil
+	}
+	filter := bson.M{
+		"bands":      bson.M{"$in": fp.Bands},
+		"reviewerId": bson.M{"$ne": review.ReviewerID},
+	}
+	cursor, err := s.commentFingerprints.Find(ctx, filter, options.Find().SetLimit(similarMatchLimit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var best *reviewFlagDocument
+	for cursor.Next(ctx) {
+		var candidate commentFingerprintDocument
+		if err := cursor.Decode(&candidate); err != nil {
+			continue
+		}
+		if candidate.ReviewID == review.ID {
+			continue
+		}
+		score := minHashSimilarity(fp.MinHash, candidate.MinHash)
+		if score < s.commentSimilarity {
+			continue
+		}
+		if best == nil || score > best.Score {
+			best = &reviewFlagDocument{
+				Code:            reviewFlagCopiedComment,
+				RelatedReviewID: candidate.ReviewID,
+				Score:           score,
+				DetectedAt:      time.Now().In(s.location),
+			}
+		}
+	}
+	return best, cursor.Err()
+}
+
+func (s *server) adminSimilarReviewClustersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		threshold := s.commentSimilarity
+		if raw := strings.TrimSpace(r.URL.Query().Get("threshold")); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || value <= 0 || value > 1 {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "thresholdは0より大きく1以下で指定してください"})
+				return
+			}
+			threshold = value
+		}
+		includeSameReviewer := r.URL.Query().Get("includeSameReviewer") == "true"
+
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+
+		cursor, err := s.commentFingerprints.Find(ctx, bson.M{})
+		if err != nil {
+			s.logger.Printf("コメント指紋の取得に失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "類似レビューの取得に失敗しました"})
+			return
+		}
+		var fingerprints []commentFingerprintDocument
+		if err := cursor.All(ctx, &fingerprints); err != nil {
+			s.logger.Printf("コメント指紋のデコードに失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "類似レビューの取得に失敗しました"})
+			return
+		}
+
+		buckets := make(map[string][]int)
+		for i, fp := range fingerprints {
+			for _, band := range fp.Bands {
+				buckets[band] = append(buckets[band], i)
+			}
+		}
+
+		parent := make([]int, len(fingerprints))
+		for i := range parent {
+			parent[i] = i
+		}
+		var find func(int) int
+		find = func(i int) int {
+			if parent[i] != i {
+				parent[i] = find(parent[i])
+			}
+			return parent[i]
+		}
+
+		seen := make(map[[2]int]struct{})
+		var pairs [][2]int
+		var scores []float64
+		for _, members := range buckets {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					a, b := members[x], members[y]
+					key := [2]int{a, b}
+					if a > b {
+						key = [2]int{b, a}
+					}
+					if _, ok := seen[key]; ok {
+						continue
+					}
+					seen[key] = struct{}{}
+					if !includeSameReviewer && fingerprints[a].ReviewerID == fingerprints[b].ReviewerID {
+						continue
+					}
+					score := minHashSimilarity(fingerprints[a].MinHash, fingerprints[b].MinHash)
+					if score < threshold {
+						continue
+					}
+					pairs = append(pairs, key)
+					scores = append(scores, score)
+					parent[find(a)] = find(b)
+				}
+			}
+		}
+
+		clusters := make(map[int]*similarClusterResponse)
+		members := make(map[int]map[int]struct{})
+		for i, pair := range pairs {
+			root := find(pair[0])
+			cluster, ok := clusters[root]
+			if !ok {
+				cluster = &similarClusterResponse{}
+				clusters[root] = cluster
+				members[root] = make(map[int]struct{})
+			}
+			members[root][pair[0]] = struct{}{}
+			members[root][pair[1]] = struct{}{}
+			cluster.Pairs = append(cluster.Pairs, similarReviewPair{
+				A:          fingerprints[pair[0]].ReviewID.Hex(),
+				B:          fingerprints[pair[1]].ReviewID.Hex(),
+				Similarity: scores[i],
+			})
+			if scores[i] > cluster.MaxSimilarity {
+				cluster.MaxSimilarity = scores[i]
+			}
+		}
+
+		reviewIDs := make([]primitive.ObjectID, 0)
+		for _, set := range members {
+			for index := range set {
+				reviewIDs = append(reviewIDs, fingerprints[index].ReviewID)
+			}
+		}
+		reviewMap := make(map[primitive.ObjectID]reviewDocument, len(reviewIDs))
+		storeIDs := make([]primitive.ObjectID, 0)
+		if len(reviewIDs) > 0 {
+			reviewCursor, err := s.reviews.Find(ctx, bson.M{"_id": bson.M{"$in": reviewIDs}})
+			if err != nil {
+				s.logger.Printf("類似レビュー本体の取得に失敗: %v", err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "類似レビューの取得に失敗しました"})
+				return
+			}
+			var reviews []reviewDocument
+			if err := reviewCursor.All(ctx, &reviews); err != nil {
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "類似レビューの取得に失敗しました"})
+				return
+			}
+			for _, review := range reviews {
+				reviewMap[review.ID] = review
+				storeIDs = append(storeIDs, review.StoreID)
+			}
+		}
+		storeMap, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.logger.Printf("類似レビュー用店舗の取得に失敗: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		items := make([]similarClusterResponse, 0, len(clusters))
+		for root, cluster := range clusters {
+			reviewerSet := make(map[string]struct{})
+			for index := range members[root] {
+				fp := fingerprints[index]
+				review, ok := reviewMap[fp.ReviewID]
+				if !ok || !isReviewFingerprinted(review) {
+					continue
+				}
+				store := storeMap[review.StoreID]
+				cluster.Reviews = append(cluster.Reviews, similarClusterReview{
+					ReviewID:   review.ID.Hex(),
+					ReviewerID: review.ReviewerID,
+					StoreID:    objectIDHex(review.StoreID),
+					StoreName:  store.Name,
+					Status:     review.Status,
+					Excerpt:    truncateRunes(review.Comment, 80),
+					CreatedAt:  review.CreatedAt,
+				})
+				reviewerSet[review.ReviewerID] = struct{}{}
+			}
+			if len(cluster.Reviews) < 2 {
+				continue
+			}
+			for reviewerID := range reviewerSet {
+				cluster.ReviewerIDs = append(cluster.ReviewerIDs, reviewerID)
+			}
+			sort.Strings(cluster.ReviewerIDs)
+			sort.Slice(cluster.Reviews, func(i, j int) bool {
+				return cluster.Reviews[i].CreatedAt.Before(cluster.Reviews[j].CreatedAt)
+			})
+			sort.Slice(cluster.Pairs, func(i, j int) bool {
+				return cluster.Pairs[i].Similarity > cluster.Pairs[j].Similarity
+			})
+			cluster.Size = len(cluster.Reviews)
+			items = append(items, *cluster)
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Size != items[j].Size {
+				return items[i].Size > items[j].Size
+			}
+			return items[i].MaxSimilarity > items[j].MaxSimilarity
+		})
+
+		s.logger.Printf("admin similar review clusters: threshold=%.2f clusters=%d", threshold, len(items))
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"threshold": threshold,
+			"items":     items,
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeComment(t *testing.T) {
+	cases := []struct {
+		name    string
+		comment string
+		want    string
+	}{
+		{"空白と句読点を除去", "とても 良い、お店です。", "とても良いお店です"},
+		{"カタカナをひらがなに", "スタッフ", "すたっふ"},
+		{"全角英数を半角小文字に", "ＡＢＣ１２３", "abc123"},
+		{"記号を除去", "★最高★!!", "最高"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(normalizeComment(tc.comment)); got != tc.want {
+				t.Errorf("normalizeComment(%q) = %q, want %q", tc.comment, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComputeCommentFingerprint(t *testing.T) {
+	original := "スタッフの対応がとても丁寧で、待機室も清潔でした。また働きたいと思えるお店です。"
+
+	t.Run("短いコメントは対象外", func(t *testing.T) {
+		if _, ok := computeCommentFingerprint("短いコメント"); ok {
+			t.Fatal("computeCommentFingerprint() ok = true, want false")
+		}
+	})
+
+	fp, ok := computeCommentFingerprint(original)
+	if !ok {
+		t.Fatal("computeCommentFingerprint() ok = false, want true")
+	}
+	if len(fp.MinHash) != minHashSize || len(fp.Bands) != minHashBands {
+		t.Fatalf("fingerprint size = %d/%d, want %d/%d", len(fp.MinHash), len(fp.Bands), minHashSize, minHashBands)
+	}
+
+	cases := []struct {
+		name    string
+		comment string
+		minSim  float64
+		maxSim  float64
+	}{
+		{"表記揺れだけの写し", "すたっふの対応がとても丁寧で 待機室も清潔でした！また働きたいと思えるお店です", 1, 1},
+		{"一部だけ書き換えた写し", strings.Replace(original, "清潔でした", "綺麗でした", 1), 0.5, 0.99},
+		{"無関係なコメント", "給料の支払いが遅れることが多く、店長の説明も曖昧で不安になりました。おすすめしません。", 0, 0.2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			other, ok := computeCommentFingerprint(tc.comment)
+			if !ok {
+				t.Fatal("computeCommentFingerprint() ok = false, want true")
+			}
+			sim := minHashSimilarity(fp.MinHash, other.MinHash)
+			if sim < tc.minSim || sim > tc.maxSim {
+				t.Errorf("minHashSimilarity() = %.2f, want %.2f..%.2f", sim, tc.minSim, tc.maxSim)
+			}
+			if tc.minSim == 1 && !reflect.DeepEqual(fp.Bands, other.Bands) {
+				t.Errorf("bands differ for identical normalised comments")
+			}
+		})
+	}
+}
+
+func TestIsReviewFingerprinted(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		review reviewDocument
+		want   bool
+	}{
+		{"審査中", reviewDocument{Status: "pending"}, true},
+		{"承認済み", reviewDocument{Status: "approved"}, true},
+		{"却下", reviewDocument{Status: "rejected"}, false},
+		{"取り下げ", reviewDocument{Status: reviewStatusWithdrawn}, false},
+		{"削除済み", reviewDocument{Status: "approved", DeletedAt: &deletedAt}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isReviewFingerprinted(tc.review); got != tc.want {
+				t.Errorf("isReviewFingerprinted() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	storeStatusOpen    = "open"
+	storeStatusClosed  = "closed"
+	storeStatusRenamed = "renamed"
+)
+
+type storeStatusRequest struct {
+	Status    string `json:"status"`
+	ClosedAt  string `json:"closedAt"`
+	Name      string `json:"name"`
+	Note      string `json:"note"`
+	UpdatedBy string `json:"updatedBy"`
+}
+
+func storeStatus(store storeDocument) string {
+	status := strings.TrimSpace(store.Status)
+	if status == "" {
+		return storeStatusOpen
+	}
+	return status
+}
+
+func isStoreClosed(store storeDocument) bool {
+	return storeStatus(store) == storeStatusClosed
+}
+
+func storeClosedOn(store storeDocument, loc *time.Location) string {
+	if !isStoreClosed(store) || store.ClosedAt == nil {
+		return ""
+	}
+	return store.ClosedAt.In(loc).Format("2006-01-02")
+}
+
+func checkStoreAcceptsVisit(store storeDocument, visitedAt string, loc *time.Location) error {
+	if !isStoreClosed(store) || store.ClosedAt == nil {
+		return nil
+	}
+	visited, err := time.ParseInLocation("2006-01", strings.TrimSpace(visitedAt), loc)
+	if err != nil {
+		return nil
+	}
+	closedAt := store.ClosedAt.In(loc)
+	closedMonth := time.Date(closedAt.Year(), closedAt.Month(), 1, 0, 0, 0, 0, loc)
+	if visited.After(closedMonth) {
+		return fmt.Errorf("この店舗は%d年%d月に閉店しているため、それ以降の時期のアンケートは受け付けていません", closedAt.Year(), int(closedAt.Month()))
+	}
+	return nil
+}
+
+func (s *server) markStoreClosed(ctx context.Context, storeID primitive.ObjectID, closedAt time.Time, note string, now time.Time) error {
+	_, err := s.stores.UpdateByID(ctx, storeID, bson.M{"$set": bson.M{
+		"status":     storeStatusClosed,
+		"closedAt":   closedAt,
+		"statusNote": note,
+		"updatedAt":  now,
+	}})
+	return err
+}
+
+// storeRenameFields は旧店舗名を previousNames に残し、旧名での投稿も同じ店舗に紐づくようにする。
+func storeRenameFields(store storeDocument, name string) bson.M {
+	previous := append([]string(nil), store.PreviousNames...)
+	if store.Name != "" && !contains(previous, store.Name) {
+		previous = append(previous, store.Name)
+	}
+	return bson.M{"name": name, "previousNames": previous}
+}
+
+func (s *server) adminStoreStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		storeID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "店舗IDの形式が不正です"})
+			return
+		}
+		var req storeStatusRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		note := strings.TrimSpace(req.Note)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		store, err := s.getStoreByID(ctx, storeID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "店舗が見つかりません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		status := strings.TrimSpace(req.Status)
+		switch status {
+		case storeStatusOpen:
+			_, err = s.stores.UpdateByID(ctx, storeID, bson.M{
+				"$set":   bson.M{"status": storeStatusOpen, "statusNote": note, "updatedAt": now},
+				"$unset": bson.M{"closedAt": ""},
+			})
+		case storeStatusClosed:
+			closedAt := now
+			if raw := strings.TrimSpace(req.ClosedAt); raw != "" {
+				closedAt, err = time.ParseInLocation("2006-01-02", raw, s.location)
+				if err != nil {
+					s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "closedAtはYYYY-MM-DD形式で指定してください"})
+					return
+				}
+			}
+			err = s.markStoreClosed(ctx, storeID, closedAt, note, now)
+		case storeStatusRenamed:
+			name := strings.TrimSpace(req.Name)
+			if name == "" || name == store.Name {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "変更後の店舗名を指定してください"})
+				return
+			}
+			update := storeRenameFields(store, name)
+			update["status"] = storeStatusRenamed
+			update["statusNote"] = note
+			update["updatedAt"] = now
+			_, err = s.stores.UpdateByID(ctx, storeID, bson.M{
+				"$set":   update,
+				"$unset": bson.M{"closedAt": ""},
+			})
+		default:
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "statusはopen、closed、renamedのいずれかを指定してください"})
+			return
+		}
+		if err != nil {
+			s.logger.Printf("店舗ステータスの更新に失敗 storeId=%s err=%v", storeID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗ステータスの更新に失敗しました"})
+			return
+		}
+
+		updated, err := s.getStoreByID(ctx, storeID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin store status id=%s from=%s to=%s by=%q", storeID.Hex(), storeStatus(store), storeStatus(updated), strings.TrimSpace(req.UpdatedBy))
+		s.writeJSON(w, http.StatusOK, storeDocumentToAdminResponse(updated))
+	}
+}
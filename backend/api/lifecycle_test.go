@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCheckStoreAcceptsVisit(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	closedAt := time.Date(2026, 6, 20, 0, 0, 0, 0, jst)
+	closed := storeDocument{Status: storeStatusClosed, ClosedAt: &closedAt}
+
+	cases := []struct {
+		name      string
+		store     storeDocument
+		visitedAt string
+		wantErr   bool
+	}{
+		{"営業中", storeDocument{}, "2026-09", false},
+		{"改名済み", storeDocument{Status: storeStatusRenamed, ClosedAt: &closedAt}, "2026-09", false},
+		{"閉店日不明", storeDocument{Status: storeStatusClosed}, "2026-09", false},
+		{"閉店前", closed, "2026-05", false},
+		{"閉店月", closed, "2026-06", false},
+		{"閉店後", closed, "2026-07", true},
+		{"時期の形式不正は判定しない", closed, "2026年7月", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkStoreAcceptsVisit(tc.store, tc.visitedAt, jst)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkStoreAcceptsVisit() err = %v, wantErr %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStoreRenameFields(t *testing.T) {
+	cases := []struct {
+		name  string
+		store storeDocument
+		want  bson.M
+	}{
+		{"初回の改名", storeDocument{Name: "旧店名"}, bson.M{"name": "新店名", "previousNames": []string{"旧店名"}}},
+		{"履歴に追記", storeDocument{Name: "二代目", PreviousNames: []string{"初代"}}, bson.M{"name": "新店名", "previousNames": []string{"初代", "二代目"}}},
+		{"現店名が履歴にあれば重複させない", storeDocument{Name: "二代目", PreviousNames: []string{"二代目"}}, bson.M{"name": "新店名", "previousNames": []string{"二代目"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := storeRenameFields(tc.store, "新店名"); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("storeRenameFields() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,10 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +21,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -45,10 +50,69 @@ type config struct {
 	messengerEndpoint    string
 	messengerDestination string
 	discordDestination   string
+	discordNotifier      string
+	discordWebhookURL    string
+	messengerSecret      string
+	messengerToken       string
+	breakerThreshold     int
+	breakerCooldown      time.Duration
 	messengerTimeout     time.Duration
 	adminReviewBaseURL   string
 	allowedOrigins       []string
 	mediaBaseURL         string
+	webhookCollection    string
+	webhookDeliveryColl  string
+	webhookOutboxColl    string
+	webhookTimeout       time.Duration
+	webhookMaxAttempts   int
+	webhookRetryBase     time.Duration
+	schedulerCollection  string
+	schedulerInterval    time.Duration
+	digestHour           int
+	reviewPendingSLA     time.Duration
+	slaMention           string
+	smtp                 smtpConfig
+	userEmailCollection  string
+	emailConfirmURL      string
+	emailConfirmRedirect string
+	emailSendColl        string
+	emailUserLimit       int
+	emailAddrLimit       int
+	emailRateWindow      time.Duration
+	emailCooldown        time.Duration
+	reviewAuditColl      string
+	reviewRevisionColl   string
+	fingerprintColl      string
+	commentSimilarity    float64
+	outlierThreshold     float64
+	outlierExclude       bool
+	reviewerProfileColl  string
+	moderationRuleColl   string
+	ngWordCollection     string
+	contentMaskMode      string
+	reviewReportColl     string
+	reportRateLimit      int
+	reportRateWindow     time.Duration
+	rewardLinkColl       string
+	rewardLedgerColl     string
+	rewardLinkKey        []byte
+	rewardAutoDelivery   bool
+	rewardMakerChecker   bool
+	adminIdentityHeader  string
+	adminProxySecret     string
+	rewardDefaultAmount  int
+	rewardPolicyColl     string
+	rewardMonthlyBudget  int
+	campaignCollection   string
+	referralCodeColl     string
+	referralColl         string
+	referralBonusAmount  int
+	referralMaxPerUser   int
+	storeRepColl         string
+	reviewResponseColl   string
+	storeCorrectionColl  string
+	statsHalfLifeDays    int
+	statsTrimPercent     int
 }
 
 type server struct {
@@ -64,9 +128,70 @@ type server struct {
 	httpClient           *http.Client
 	messengerEndpoint    string
 	messengerDestination string
+	messengerSecret      string
+	messengerToken       string
+	messengerBreaker     *circuitBreaker
 	discordDestination   string
+	lineNotifier         notifier
+	discordNotifier      notifier
 	adminReviewBaseURL   string
 	mediaBaseURL         string
+	webhooks             *mongo.Collection
+	webhookDeliveries    *mongo.Collection
+	webhookOutbox        *mongo.Collection
+	webhookClient        *http.Client
+	webhookMaxAttempts   int
+	webhookRetryBase     time.Duration
+	schedulerLeases      *mongo.Collection
+	instanceID           string
+	schedulerInterval    time.Duration
+	digestHour           int
+	reviewPendingSLA     time.Duration
+	slaEscalationMention string
+	stopBackground       context.CancelFunc
+	maintenanceBusy      atomic.Bool
+	emailNotifier        notifier
+	userEmails           *mongo.Collection
+	emailConfirmURL      string
+	emailRedirectURL     string
+	emailSends           *mongo.Collection
+	emailUserLimit       int
+	emailAddrLimit       int
+	emailRateWindow      time.Duration
+	emailCooldown        time.Duration
+	reviewAudit          *mongo.Collection
+	reviewRevisions      *mongo.Collection
+	commentFingerprints  *mongo.Collection
+	commentSimilarity    float64
+	outlierThreshold     float64
+	outlierExclude       bool
+	reviewerProfiles     *mongo.Collection
+	moderationRules      *mongo.Collection
+	ngWords              *mongo.Collection
+	contentMaskMode      string
+	reviewReports        *mongo.Collection
+	reportRateLimit      int
+	reportRateWindow     time.Duration
+	rewardLinks          *mongo.Collection
+	rewardLedger         *mongo.Collection
+	rewardCipher         cipher.AEAD
+	rewardAutoDelivery   bool
+	rewardMakerChecker   bool
+	adminIdentityHeader  string
+	adminProxySecret     string
+	rewardDefaultAmount  int
+	rewardPolicies       *mongo.Collection
+	rewardMonthlyBudget  int
+	campaigns            *mongo.Collection
+	referralCodes        *mongo.Collection
+	referrals            *mongo.Collection
+	referralBonusAmount  int
+	referralMaxPerUser   int
+	storeReps            *mongo.Collection
+	reviewResponses      *mongo.Collection
+	storeCorrections     *mongo.Collection
+	statsHalfLifeDays    int
+	statsTrimPercent     int
 }
 
 type jwtConfig struct {
@@ -101,7 +226,12 @@ type storeDocument struct {
 	BranchName    string             `bson:"branchName,omitempty"`
 	Prefecture    string             `bson:"prefecture,omitempty"`
 	IndustryCodes []string           `bson:"industryCodes,omitempty"`
+	Status        string             `bson:"status,omitempty"`
+	ClosedAt      *time.Time         `bson:"closedAt,omitempty"`
+	StatusNote    string             `bson:"statusNote,omitempty"`
+	PreviousNames []string           `bson:"previousNames,omitempty"`
 	Stats         storeStatsDocument `bson:"stats"`
+	RobustStats   storeRobustStats   `bson:"robustStats,omitempty"`
 	CreatedAt     *time.Time         `bson:"createdAt,omitempty"`
 	UpdatedAt     *time.Time         `bson:"updatedAt,omitempty"`
 }
@@ -112,9 +242,19 @@ type reviewAttachmentDocument struct {
 }
 
 type reviewRewardDocument struct {
-	Status string     `bson:"status"`
-	SentAt *time.Time `bson:"sentAt,omitempty"`
-	Note   string     `bson:"note,omitempty"`
+	Status      string              `bson:"status"`
+	SentAt      *time.Time          `bson:"sentAt,omitempty"`
+	Note        string              `bson:"note,omitempty"`
+	LinkID      *primitive.ObjectID `bson:"linkId,omitempty"`
+	Amount      int                 `bson:"amount,omitempty"`
+	PolicyID    *primitive.ObjectID `bson:"policyId,omitempty"`
+	HoldCode    string              `bson:"holdCode,omitempty"`
+	HoldReason  string              `bson:"holdReason,omitempty"`
+	CommittedAt *time.Time          `bson:"committedAt,omitempty"`
+	RequestedBy string              `bson:"requestedBy,omitempty"`
+	RequestedAt *time.Time          `bson:"requestedAt,omitempty"`
+	ConfirmedBy string              `bson:"confirmedBy,omitempty"`
+	ConfirmedAt *time.Time          `bson:"confirmedAt,omitempty"`
 }
 
 type reviewDocument struct {
@@ -137,6 +277,20 @@ type reviewDocument struct {
 	ReviewerID       string                     `bson:"reviewerId,omitempty"`
 	ReviewerName     string                     `bson:"reviewerName,omitempty"`
 	ReviewerUsername string                     `bson:"reviewerUsername,omitempty"`
+	SLAReminderTier  int                        `bson:"slaReminderTier,omitempty"`
+	WithdrawnAt      *time.Time                 `bson:"withdrawnAt,omitempty"`
+	Feedback         *reviewFeedbackDocument    `bson:"feedback,omitempty"`
+	Revision         int                        `bson:"revision,omitempty"`
+	ResubmittedAt    *time.Time                 `bson:"resubmittedAt,omitempty"`
+	PublishedRev     int                        `bson:"publishedRevision,omitempty"`
+	Flags            []reviewFlagDocument       `bson:"flags,omitempty"`
+	Anomaly          *reviewAnomalyDocument     `bson:"anomaly,omitempty"`
+	Moderation       *reviewModerationDocument  `bson:"moderation,omitempty"`
+	ContentFindings  []contentFindingDocument   `bson:"contentFindings,omitempty"`
+	DeletedAt        *time.Time                 `bson:"deletedAt,omitempty"`
+	DeletedBy        string                     `bson:"deletedBy,omitempty"`
+	DeleteReason     string                     `bson:"deleteReason,omitempty"`
+	Campaign         *reviewCampaignDocument    `bson:"campaign,omitempty"`
 	CreatedAt        time.Time                  `bson:"createdAt"`
 	UpdatedAt        time.Time                  `bson:"updatedAt"`
 }
@@ -158,6 +312,33 @@ func main() {
 	if err := srv.ensureSamplePing(context.Background()); err != nil {
 		cfg.serverLog.Printf("サンプル ping ドキュメントの用意に失敗しました: %v", err)
 	}
+	if err := srv.ensureWebhookOutboxIndex(ctx); err != nil {
+		cfg.serverLog.Printf("Webhook 配信キューインデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureEmailSendIndexes(ctx); err != nil {
+		cfg.serverLog.Printf("確認メール送信記録インデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureReviewDedupeIndex(ctx); err != nil {
+		cfg.serverLog.Printf("重複アンケート防止インデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureReviewRevisionIndex(ctx); err != nil {
+		cfg.serverLog.Printf("リビジョンインデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureCommentFingerprintIndex(ctx); err != nil {
+		cfg.serverLog.Printf("コメント指紋インデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureRewardLinkIndex(ctx); err != nil {
+		cfg.serverLog.Printf("報酬リンクインデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureReferralIndexes(ctx); err != nil {
+		cfg.serverLog.Printf("紹介インデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureReviewResponseIndexes(ctx); err != nil {
+		cfg.serverLog.Printf("公式返信インデックスの作成に失敗しました: %v", err)
+	}
+	if err := srv.ensureStoreCorrectionIndex(ctx); err != nil {
+		cfg.serverLog.Printf("店舗修正提案インデックスの作成に失敗しました: %v", err)
+	}
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
@@ -169,21 +350,95 @@ func main() {
 	router.Get("/healthz", srv.healthHandler())
 	router.Get("/ping", srv.pingHandler())
 	router.Get("/stores", srv.storeListHandler())
+	router.Get("/campaigns", srv.campaignListHandler())
 	router.Get("/reviews", srv.reviewListHandler())
 	router.Get("/reviews/new", srv.reviewLatestHandler())
 	router.Get("/reviews/high-rated", srv.reviewHighRatedHandler())
+	router.Get("/reviews/report-reasons", srv.reportReasonListHandler())
 	router.Get("/reviews/{id}", srv.reviewDetailHandler)
+	router.With(srv.authMiddleware).Post("/reviews/{id}/reports", srv.reviewReportCreateHandler())
+	router.With(srv.authMiddleware).Post("/stores/{id}/corrections", srv.storeCorrectionCreateHandler())
+	router.With(srv.authMiddleware).Post("/reviews/{id}/response", srv.reviewResponseSubmitHandler())
 	router.With(srv.authMiddleware).Post("/reviews", srv.reviewCreateHandler())
 	router.With(srv.authMiddleware).Get("/auth/verify", srv.authVerifyHandler())
+	router.With(srv.authMiddleware).Get("/me/email", srv.userEmailGetHandler())
+	router.With(srv.authMiddleware).Put("/me/email", srv.userEmailUpdateHandler())
+	router.With(srv.authMiddleware).Delete("/me/email", srv.userEmailDeleteHandler())
+	router.Get("/me/email/confirm", srv.userEmailConfirmHandler())
+	router.With(srv.authMiddleware).Get("/me/reviews", srv.myReviewListHandler())
+	router.With(srv.authMiddleware).Get("/me/reviews/{id}", srv.myReviewDetailHandler())
+	router.With(srv.authMiddleware).Patch("/me/reviews/{id}", srv.myReviewUpdateHandler())
+	router.With(srv.authMiddleware).Delete("/me/reviews/{id}", srv.myReviewWithdrawHandler())
+	router.With(srv.authMiddleware).Post("/me/reviews/{id}/resubmit", srv.myReviewResubmitHandler())
+	router.With(srv.authMiddleware).Get("/me/referral", srv.myReferralHandler())
+	router.With(srv.authMiddleware).Get("/me/stores", srv.myStoreRepresentationHandler())
 	router.Route("/admin", func(r chi.Router) {
+		r.Use(srv.adminIdentityMiddleware)
 		r.Get("/reviews", srv.adminReviewListHandler())
+		r.Get("/reviews/feedback-reasons", srv.adminFeedbackReasonListHandler())
+		r.Get("/reviews/similar", srv.adminSimilarReviewClustersHandler())
 		r.Get("/reviews/{id}", srv.adminReviewDetailHandler())
 		r.Patch("/reviews/{id}", srv.adminReviewUpdateHandler())
 		r.Patch("/reviews/{id}/status", srv.adminReviewStatusHandler())
+		r.Get("/reviews/{id}/revisions", srv.adminReviewRevisionListHandler())
+		r.Patch("/reviews/{id}/anomaly", srv.adminReviewAnomalyHandler())
+		r.Delete("/reviews/{id}", srv.adminReviewDeleteHandler())
+		r.Post("/reviews/{id}/unpublish", srv.adminReviewUnpublishHandler())
+		r.Post("/reviews/{id}/restore", srv.adminReviewRestoreHandler())
+		r.Post("/reviews/{id}/revisions/{revision}/restore", srv.adminReviewRevisionRestoreHandler())
 		r.Get("/stores", srv.adminStoreSearchHandler())
 		r.Post("/stores", srv.adminStoreCreateHandler())
+		r.Post("/stores/{id}/representatives", srv.adminStoreRepresentativeGrantHandler())
+		r.Patch("/stores/{id}/status", srv.adminStoreStatusHandler())
+		r.Get("/store-representatives", srv.adminStoreRepresentativeListHandler())
+		r.Get("/store-corrections", srv.adminStoreCorrectionListHandler())
+		r.Post("/store-corrections/{id}/resolve", srv.adminStoreCorrectionResolveHandler())
+		r.Delete("/store-representatives/{id}", srv.adminStoreRepresentativeRevokeHandler())
+		r.Get("/review-responses", srv.adminReviewResponseQueueHandler())
+		r.Post("/review-responses/{id}/moderate", srv.adminReviewResponseModerateHandler())
+		r.Get("/moderation-rules", srv.adminModerationRuleListHandler())
+		r.Post("/moderation-rules", srv.adminModerationRuleCreateHandler())
+		r.Put("/moderation-rules/{id}", srv.adminModerationRuleUpdateHandler())
+		r.Delete("/moderation-rules/{id}", srv.adminModerationRuleDeleteHandler())
+		r.Get("/reviewers/{id}/profile", srv.adminReviewerProfileHandler())
+		r.Get("/reports", srv.adminReportQueueHandler())
+		r.Post("/reviews/{id}/reward/deliver", srv.adminReviewRewardDeliverHandler())
+		r.Get("/reward-links", srv.adminRewardLinkListHandler())
+		r.Post("/reward-links/import", srv.adminRewardLinkImportHandler())
+		r.Post("/reward-links/{id}/void", srv.adminRewardLinkVoidHandler())
+		r.Get("/reward-ledger", srv.adminRewardLedgerHandler())
+		r.Get("/reward-budget", srv.adminRewardBudgetHandler())
+		r.Get("/referrals", srv.adminReferralReportHandler())
+		r.Get("/campaigns", srv.adminCampaignListHandler())
+		r.Post("/campaigns", srv.adminCampaignCreateHandler())
+		r.Get("/campaigns/report", srv.adminCampaignReportHandler())
+		r.Put("/campaigns/{id}", srv.adminCampaignUpdateHandler())
+		r.Delete("/campaigns/{id}", srv.adminCampaignDeleteHandler())
+		r.Get("/reward-policies", srv.adminRewardPolicyListHandler())
+		r.Post("/reward-policies", srv.adminRewardPolicyCreateHandler())
+		r.Put("/reward-policies/{id}", srv.adminRewardPolicyUpdateHandler())
+		r.Delete("/reward-policies/{id}", srv.adminRewardPolicyDeleteHandler())
+		r.Post("/reviews/{id}/reward/release", srv.adminReviewRewardReleaseHandler())
+		r.Get("/rewards/awaiting-confirmation", srv.adminRewardAwaitingListHandler())
+		r.Post("/reviews/{id}/reward/confirm", srv.adminRewardConfirmHandler())
+		r.Get("/rewards/export", srv.adminRewardExportHandler())
+		r.Post("/rewards/import", srv.adminRewardReconcileHandler())
+		r.Post("/reviews/{id}/reports/resolve", srv.adminReportResolveHandler())
+		r.Get("/ng-words", srv.adminNGWordListHandler())
+		r.Post("/ng-words", srv.adminNGWordCreateHandler())
+		r.Delete("/ng-words/{id}", srv.adminNGWordDeleteHandler())
+		r.Get("/webhooks", srv.adminWebhookListHandler())
+		r.Post("/webhooks", srv.adminWebhookCreateHandler())
+		r.Patch("/webhooks/{id}", srv.adminWebhookUpdateHandler())
+		r.Delete("/webhooks/{id}", srv.adminWebhookDeleteHandler())
+		r.Get("/webhooks/{id}/deliveries", srv.adminWebhookDeliveryListHandler())
 	})
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	srv.stopBackground = stopBackground
+	go srv.runScheduler(backgroundCtx)
+	go srv.runWebhookDispatcher(backgroundCtx)
+
 	httpServer := &http.Server{
 		Addr:              cfg.addr,
 		Handler:           router,
@@ -218,6 +473,14 @@ func loadConfig() config {
 	}
 
 	discordDestination := strings.TrimSpace(os.Getenv("MESSENGER_DISCORD_INCOMING_DESTINATION"))
+	discordWebhookURL := strings.TrimSpace(os.Getenv("DISCORD_WEBHOOK_URL"))
+	discordNotifier := strings.ToLower(strings.TrimSpace(os.Getenv("DISCORD_NOTIFIER")))
+	if discordNotifier == "" {
+		discordNotifier = discordNotifierGateway
+		if discordWebhookURL != "" {
+			discordNotifier = discordNotifierWebhook
+		}
+	}
 
 	messengerTimeout := 3 * time.Second
 	if raw := strings.TrimSpace(os.Getenv("MESSENGER_GATEWAY_TIMEOUT")); raw != "" {
@@ -225,6 +488,87 @@ func loadConfig() config {
 			messengerTimeout = parsed
 		}
 	}
+	breakerThreshold, _ := parsePositiveInt(os.Getenv("MESSENGER_BREAKER_THRESHOLD"), 5)
+	breakerCooldown := 30 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("MESSENGER_BREAKER_COOLDOWN")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			breakerCooldown = parsed
+		}
+	}
+	commentSimilarity := 0.7
+	if raw := strings.TrimSpace(os.Getenv("COMMENT_SIMILARITY_THRESHOLD")); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed > 0 && parsed <= 1 {
+			commentSimilarity = parsed
+		}
+	}
+	outlierThreshold := 3.5
+	if raw := strings.TrimSpace(os.Getenv("OUTLIER_THRESHOLD")); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed > 0 {
+			outlierThreshold = parsed
+		}
+	}
+	outlierExclude, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("OUTLIER_EXCLUDE_FROM_STATS")))
+	contentMaskMode := strings.ToLower(envOrDefault("CONTENT_MASK_MODE", contentMaskModeMask))
+	if contentMaskMode != contentMaskModeOff {
+		contentMaskMode = contentMaskModeMask
+	}
+	emailUserLimit, _ := parsePositiveInt(os.Getenv("EMAIL_CONFIRM_USER_LIMIT"), 5)
+	emailAddrLimit, _ := parsePositiveInt(os.Getenv("EMAIL_CONFIRM_ADDRESS_LIMIT"), 3)
+	emailRateWindow := 24 * time.Hour
+	if raw := strings.TrimSpace(os.Getenv("EMAIL_CONFIRM_WINDOW")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			emailRateWindow = parsed
+		}
+	}
+	emailCooldown := time.Minute
+	if raw := strings.TrimSpace(os.Getenv("EMAIL_CONFIRM_COOLDOWN")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+			emailCooldown = parsed
+		}
+	}
+	reportRateLimit, _ := parsePositiveInt(os.Getenv("REPORT_RATE_LIMIT"), 5)
+	reportRateWindow := time.Hour
+	if raw := strings.TrimSpace(os.Getenv("REPORT_RATE_WINDOW")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			reportRateWindow = parsed
+		}
+	}
+	rewardLinkKey, err := parseRewardLinkKey(os.Getenv("REWARD_LINK_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Printf("報酬リンクの自動送信を無効化します: %v", err)
+	}
+	rewardAutoDelivery := true
+	if raw := strings.TrimSpace(os.Getenv("REWARD_AUTO_DELIVERY")); raw != "" {
+		if parsed, err := strconv.ParseBool(raw); err == nil {
+			rewardAutoDelivery = parsed
+		}
+	}
+	rewardMakerChecker := false
+	if raw := strings.TrimSpace(os.Getenv("REWARD_MAKER_CHECKER")); raw != "" {
+		if parsed, err := strconv.ParseBool(raw); err == nil {
+			rewardMakerChecker = parsed
+		}
+	}
+	rewardDefaultAmount, _ := parsePositiveInt(os.Getenv("REWARD_DEFAULT_AMOUNT"), 1000)
+	rewardMonthlyBudget, _ := parsePositiveInt(os.Getenv("REWARD_MONTHLY_BUDGET"), 0)
+	referralBonusAmount, _ := parsePositiveInt(os.Getenv("REFERRAL_BONUS_AMOUNT"), 500)
+	referralMaxPerUser, _ := parsePositiveInt(os.Getenv("REFERRAL_MAX_PER_USER"), 10)
+	statsHalfLifeDays := 365
+	if raw := strings.TrimSpace(os.Getenv("STATS_HALF_LIFE_DAYS")); raw != "" {
+		parsed, ok := parseNonNegativeInt(raw, statsHalfLifeDays)
+		if !ok {
+			log.Printf("STATS_HALF_LIFE_DAYS が不正なため既定値 %d を使用します: %q", statsHalfLifeDays, raw)
+		}
+		statsHalfLifeDays = parsed
+	}
+	statsTrimPercent := 10
+	if raw := strings.TrimSpace(os.Getenv("STATS_TRIM_PERCENT")); raw != "" {
+		if parsed, ok := parseNonNegativeInt(raw, statsTrimPercent); ok && parsed < 50 {
+			statsTrimPercent = parsed
+		} else {
+			log.Printf("STATS_TRIM_PERCENT は0以上50未満で指定してください。既定値 %d を使用します: %q", statsTrimPercent, raw)
+		}
+	}
 	allowedOrigins := parseList("API_ALLOWED_ORIGINS", []string{"*"})
 	adminReviewBaseURL := strings.TrimSpace(os.Getenv("ADMIN_REVIEW_BASE_URL"))
 
@@ -254,6 +598,59 @@ func loadConfig() config {
 		jwtAudience = strings.TrimSpace(os.Getenv("AUTH_TWITTER_JWT_AUDIENCE"))
 	}
 
+	webhookTimeout := 5 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("WEBHOOK_TIMEOUT")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			webhookTimeout = parsed
+		} else {
+			log.Printf("WEBHOOK_TIMEOUT は正の時間で指定してください。既定値 %s を使用します: %q", webhookTimeout, raw)
+		}
+	}
+	webhookRetryBase := 2 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("WEBHOOK_RETRY_BASE")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			webhookRetryBase = parsed
+		}
+	}
+	webhookMaxAttempts, _ := parsePositiveInt(os.Getenv("WEBHOOK_MAX_ATTEMPTS"), 5)
+
+	schedulerInterval := time.Minute
+	if raw := strings.TrimSpace(os.Getenv("SCHEDULER_INTERVAL")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			schedulerInterval = parsed
+		}
+	}
+	if enabled := strings.ToLower(strings.TrimSpace(os.Getenv("SCHEDULER_ENABLED"))); enabled == "false" || enabled == "0" {
+		schedulerInterval = 0
+	}
+	reviewPendingSLA := 48 * time.Hour
+	if raw := strings.TrimSpace(os.Getenv("REVIEW_PENDING_SLA")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			reviewPendingSLA = parsed
+		}
+	}
+	digestHour := 9
+	if parsed, ok := parseInt(strings.TrimSpace(os.Getenv("DIGEST_HOUR"))); ok && parsed >= 0 && parsed < 24 {
+		digestHour = parsed
+	}
+
+	smtpTimeout := 10 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("SMTP_TIMEOUT")); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			smtpTimeout = parsed
+		}
+	}
+	smtpPort, _ := parsePositiveInt(os.Getenv("SMTP_PORT"), 587)
+	smtpSettings := smtpConfig{
+		host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		port:     smtpPort,
+		username: strings.TrimSpace(os.Getenv("SMTP_USERNAME")),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+		tlsMode:  strings.ToLower(envOrDefault("SMTP_STARTTLS", smtpTLSRequired)),
+		timeout:  smtpTimeout,
+	}
+
 	storeCollection := envOrDefault("STORE_COLLECTION", "stores")
 	reviewCollection := strings.TrimSpace(os.Getenv("REVIEW_COLLECTION"))
 	if reviewCollection == "" {
@@ -275,13 +672,72 @@ func loadConfig() config {
 		messengerEndpoint:    messengerEndpoint,
 		messengerDestination: messengerDestination,
 		discordDestination:   discordDestination,
+		discordNotifier:      discordNotifier,
+		discordWebhookURL:    discordWebhookURL,
+		messengerSecret:      strings.TrimSpace(os.Getenv("MESSENGER_GATEWAY_SECRET")),
+		messengerToken:       strings.TrimSpace(os.Getenv("MESSENGER_GATEWAY_TOKEN")),
+		breakerThreshold:     breakerThreshold,
+		breakerCooldown:      breakerCooldown,
 		messengerTimeout:     messengerTimeout,
 		adminReviewBaseURL:   adminReviewBaseURL,
 		allowedOrigins:       allowedOrigins,
 		mediaBaseURL:         strings.TrimSpace(os.Getenv("MEDIA_BASE_URL")),
-	}
-
-	cfgStruct.serverLog.Printf("loaded config: adminReviewBaseURL=%q messengerEndpoint=%q destination=%q", adminReviewBaseURL, messengerEndpoint, messengerDestination)
+		webhookCollection:    envOrDefault("WEBHOOK_COLLECTION", "webhooks"),
+		webhookDeliveryColl:  envOrDefault("WEBHOOK_DELIVERY_COLLECTION", "webhookDeliveries"),
+		webhookOutboxColl:    envOrDefault("WEBHOOK_OUTBOX_COLLECTION", "webhookOutbox"),
+		webhookTimeout:       webhookTimeout,
+		webhookMaxAttempts:   webhookMaxAttempts,
+		webhookRetryBase:     webhookRetryBase,
+		schedulerCollection:  envOrDefault("SCHEDULER_LEASE_COLLECTION", "schedulerLeases"),
+		schedulerInterval:    schedulerInterval,
+		digestHour:           digestHour,
+		reviewPendingSLA:     reviewPendingSLA,
+		slaMention:           strings.TrimSpace(os.Getenv("SLA_ESCALATION_MENTION")),
+		smtp:                 smtpSettings,
+		userEmailCollection:  envOrDefault("USER_EMAIL_COLLECTION", "userEmails"),
+		emailConfirmURL:      strings.TrimSpace(os.Getenv("EMAIL_CONFIRM_URL")),
+		emailConfirmRedirect: strings.TrimSpace(os.Getenv("EMAIL_CONFIRM_REDIRECT_URL")),
+		emailSendColl:        envOrDefault("EMAIL_CONFIRM_SEND_COLLECTION", "emailConfirmSends"),
+		emailUserLimit:       emailUserLimit,
+		emailAddrLimit:       emailAddrLimit,
+		emailRateWindow:      emailRateWindow,
+		emailCooldown:        emailCooldown,
+		reviewAuditColl:      envOrDefault("REVIEW_AUDIT_COLLECTION", "reviewAudit"),
+		reviewRevisionColl:   envOrDefault("REVIEW_REVISION_COLLECTION", "reviewRevisions"),
+		fingerprintColl:      envOrDefault("COMMENT_FINGERPRINT_COLLECTION", "commentFingerprints"),
+		commentSimilarity:    commentSimilarity,
+		outlierThreshold:     outlierThreshold,
+		outlierExclude:       outlierExclude,
+		reviewerProfileColl:  envOrDefault("REVIEWER_PROFILE_COLLECTION", "reviewerProfiles"),
+		moderationRuleColl:   envOrDefault("MODERATION_RULE_COLLECTION", "moderationRules"),
+		ngWordCollection:     envOrDefault("NG_WORD_COLLECTION", "ngWords"),
+		contentMaskMode:      contentMaskMode,
+		reviewReportColl:     envOrDefault("REVIEW_REPORT_COLLECTION", "reviewReports"),
+		reportRateLimit:      reportRateLimit,
+		reportRateWindow:     reportRateWindow,
+		rewardLinkColl:       envOrDefault("REWARD_LINK_COLLECTION", "rewardLinks"),
+		rewardLedgerColl:     envOrDefault("REWARD_LEDGER_COLLECTION", "rewardLedger"),
+		rewardLinkKey:        rewardLinkKey,
+		rewardAutoDelivery:   rewardAutoDelivery,
+		rewardMakerChecker:   rewardMakerChecker,
+		adminIdentityHeader:  envOrDefault("ADMIN_IDENTITY_HEADER", "X-Admin-User"),
+		adminProxySecret:     strings.TrimSpace(os.Getenv("ADMIN_PROXY_SECRET")),
+		rewardDefaultAmount:  rewardDefaultAmount,
+		rewardPolicyColl:     envOrDefault("REWARD_POLICY_COLLECTION", "rewardPolicies"),
+		rewardMonthlyBudget:  rewardMonthlyBudget,
+		campaignCollection:   envOrDefault("CAMPAIGN_COLLECTION", "campaigns"),
+		referralCodeColl:     envOrDefault("REFERRAL_CODE_COLLECTION", "referralCodes"),
+		referralColl:         envOrDefault("REFERRAL_COLLECTION", "referrals"),
+		referralBonusAmount:  referralBonusAmount,
+		referralMaxPerUser:   referralMaxPerUser,
+		storeRepColl:         envOrDefault("STORE_REPRESENTATIVE_COLLECTION", "storeRepresentatives"),
+		reviewResponseColl:   envOrDefault("REVIEW_RESPONSE_COLLECTION", "reviewResponses"),
+		storeCorrectionColl:  envOrDefault("STORE_CORRECTION_COLLECTION", "storeCorrections"),
+		statsHalfLifeDays:    statsHalfLifeDays,
+		statsTrimPercent:     statsTrimPercent,
+	}
+
+	cfgStruct.serverLog.Printf("loaded config: adminReviewBaseURL=%q messengerEndpoint=%q destination=%q discordNotifier=%q", adminReviewBaseURL, messengerEndpoint, messengerDestination, discordNotifier)
 
 	return cfgStruct
 }
@@ -348,7 +804,7 @@ func withCORS(origins []string) func(http.Handler) http.Handler {
 
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Add("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PATCH,OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type")
 			w.Header().Set("Access-Control-Max-Age", "300")
 
@@ -463,13 +919,31 @@ func (s *server) getStoreByID(ctx context.Context, id primitive.ObjectID) (store
 	return store, err
 }
 
-func (s *server) findOrCreateStore(ctx context.Context, name, branch, prefecture, category string) (storeDocument, error) {
+func (s *server) applyStoreUpdate(ctx context.Context, storeID primitive.ObjectID, storeUpdate bson.M, addIndustry string, now time.Time) error {
+	if storeID.IsZero() {
+		return nil
+	}
+	if len(storeUpdate) > 0 {
+		storeUpdate["updatedAt"] = now
+		if _, err := s.stores.UpdateByID(ctx, storeID, bson.M{"$set": storeUpdate}); err != nil {
+			return err
+		}
+	}
+	if addIndustry != "" {
+		if _, err := s.stores.UpdateByID(ctx, storeID, bson.M{"$addToSet": bson.M{"industryCodes": addIndustry}}); err != nil {
+			s.logger.Printf("店舗の業種追加に失敗 storeId=%s err=%v", storeID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func (s *server) findOrCreateStore(ctx context.Context, name, branch, prefecture, category string) (storeDocument, bool, error) {
 	name = strings.TrimSpace(name)
 	branch = strings.TrimSpace(branch)
 	prefecture = strings.TrimSpace(prefecture)
 	category = canonicalIndustryCode(category)
 	if name == "" {
-		return storeDocument{}, errors.New("店舗名が指定されていません")
+		return storeDocument{}, false, errors.New("店舗名が指定されていません")
 	}
 
 	filter := bson.M{"name": name}
@@ -482,11 +956,16 @@ func (s *server) findOrCreateStore(ctx context.Context, name, branch, prefecture
 
 	var store storeDocument
 	err := s.stores.FindOne(ctx, filter).Decode(&store)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		delete(filter, "name")
+		filter["previousNames"] = name
+		err = s.stores.FindOne(ctx, filter).Decode(&store)
+	}
 	if err == nil {
-		return store, nil
+		return store, false, nil
 	}
 	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
-		return storeDocument{}, err
+		return storeDocument{}, false, err
 	}
 
 	now := time.Now().In(s.location)
@@ -511,18 +990,20 @@ func (s *server) findOrCreateStore(ctx context.Context, name, branch, prefecture
 	}
 
 	if _, err := s.stores.InsertOne(ctx, doc); err != nil {
-		return storeDocument{}, err
+		return storeDocument{}, false, err
 	}
 
-	return s.getStoreByID(ctx, newID)
+	store, err = s.getStoreByID(ctx, newID)
+	return store, true, err
 }
 
 func (s *server) recalculateStoreStats(ctx context.Context, storeID primitive.ObjectID) error {
+	match := publicReviewFilter(bson.M{
+		"storeId":          storeID,
+		"anomaly.excluded": bson.M{"$ne": true},
+	})
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"storeId": storeID,
-			"status":  "approved",
-		}}},
+		{{Key: "$match", Value: match}},
 		{{Key: "$group", Value: bson.M{
 			"_id":            nil,
 			"reviewCount":    bson.M{"$sum": 1},
@@ -569,6 +1050,12 @@ func (s *server) recalculateStoreStats(ctx context.Context, storeID primitive.Ob
 		return err
 	}
 
+	robust, err := s.computeRobustStats(ctx, match, time.Now().In(s.location))
+	if err != nil {
+		return err
+	}
+	update["robustStats"] = robust
+
 	_, err = s.stores.UpdateByID(ctx, storeID, bson.M{"$set": update})
 	return err
 }
@@ -595,13 +1082,95 @@ func newServer(cfg config, client *mongo.Client) *server {
 		httpClient:           &http.Client{Timeout: cfg.messengerTimeout},
 		messengerEndpoint:    endpoint,
 		messengerDestination: cfg.messengerDestination,
+		messengerSecret:      cfg.messengerSecret,
+		messengerToken:       cfg.messengerToken,
+		messengerBreaker:     newCircuitBreaker(cfg.breakerThreshold, cfg.breakerCooldown),
 		discordDestination:   cfg.discordDestination,
 		adminReviewBaseURL:   cfg.adminReviewBaseURL,
 		mediaBaseURL:         strings.TrimSuffix(strings.TrimSpace(cfg.mediaBaseURL), "/"),
+		webhookClient:        &http.Client{Timeout: cfg.webhookTimeout},
+		webhookMaxAttempts:   cfg.webhookMaxAttempts,
+		webhookRetryBase:     cfg.webhookRetryBase,
+		instanceID:           newInstanceID(),
+		schedulerInterval:    cfg.schedulerInterval,
+		digestHour:           cfg.digestHour,
+		reviewPendingSLA:     cfg.reviewPendingSLA,
+		slaEscalationMention: cfg.slaMention,
+		emailConfirmURL:      cfg.emailConfirmURL,
+		emailRedirectURL:     cfg.emailConfirmRedirect,
+		emailUserLimit:       cfg.emailUserLimit,
+		emailAddrLimit:       cfg.emailAddrLimit,
+		emailRateWindow:      cfg.emailRateWindow,
+		emailCooldown:        cfg.emailCooldown,
 	}
 	srv.pings = srv.database.Collection(cfg.pingCollection)
 	srv.stores = srv.database.Collection(cfg.storeCollection)
 	srv.reviews = srv.database.Collection(cfg.reviewCollection)
+	srv.lineNotifier = &messengerNotifier{srv: srv, destination: cfg.messengerDestination}
+	switch {
+	case cfg.discordNotifier == discordNotifierWebhook && cfg.discordWebhookURL != "":
+		srv.discordNotifier = newDiscordWebhookNotifier(&http.Client{Timeout: cfg.messengerTimeout}, cfg.discordWebhookURL, cfg.adminReviewBaseURL, cfg.serverLog)
+	case cfg.discordNotifier == discordNotifierWebhook:
+		cfg.serverLog.Printf("DISCORD_WEBHOOK_URL が未設定のため Discord 通知はゲートウェイ経由で送信します")
+		fallthrough
+	default:
+		if dest := strings.TrimSpace(cfg.discordDestination); dest != "" {
+			srv.discordNotifier = &messengerNotifier{srv: srv, destination: dest}
+		}
+	}
+	srv.webhooks = srv.database.Collection(cfg.webhookCollection)
+	srv.webhookDeliveries = srv.database.Collection(cfg.webhookDeliveryColl)
+	srv.webhookOutbox = srv.database.Collection(cfg.webhookOutboxColl)
+	srv.schedulerLeases = srv.database.Collection(cfg.schedulerCollection)
+	srv.userEmails = srv.database.Collection(cfg.userEmailCollection)
+	srv.emailSends = srv.database.Collection(cfg.emailSendColl)
+	srv.reviewAudit = srv.database.Collection(cfg.reviewAuditColl)
+	srv.reviewRevisions = srv.database.Collection(cfg.reviewRevisionColl)
+	srv.commentFingerprints = srv.database.Collection(cfg.fingerprintColl)
+	srv.commentSimilarity = cfg.commentSimilarity
+	srv.outlierThreshold = cfg.outlierThreshold
+	srv.outlierExclude = cfg.outlierExclude
+	srv.reviewerProfiles = srv.database.Collection(cfg.reviewerProfileColl)
+	srv.moderationRules = srv.database.Collection(cfg.moderationRuleColl)
+	srv.ngWords = srv.database.Collection(cfg.ngWordCollection)
+	srv.contentMaskMode = cfg.contentMaskMode
+	srv.reviewReports = srv.database.Collection(cfg.reviewReportColl)
+	srv.reportRateLimit = cfg.reportRateLimit
+	srv.reportRateWindow = cfg.reportRateWindow
+	srv.rewardLinks = srv.database.Collection(cfg.rewardLinkColl)
+	srv.rewardLedger = srv.database.Collection(cfg.rewardLedgerColl)
+	srv.rewardAutoDelivery = cfg.rewardAutoDelivery
+	srv.rewardMakerChecker = cfg.rewardMakerChecker
+	srv.adminIdentityHeader = cfg.adminIdentityHeader
+	srv.adminProxySecret = cfg.adminProxySecret
+	if srv.rewardMakerChecker && srv.adminProxySecret == "" {
+		cfg.serverLog.Printf("REWARD_MAKER_CHECKER が有効ですが ADMIN_PROXY_SECRET が未設定のため、謝礼の確認を受け付けられません")
+	}
+	srv.rewardDefaultAmount = cfg.rewardDefaultAmount
+	srv.rewardPolicies = srv.database.Collection(cfg.rewardPolicyColl)
+	srv.rewardMonthlyBudget = cfg.rewardMonthlyBudget
+	srv.campaigns = srv.database.Collection(cfg.campaignCollection)
+	srv.referralCodes = srv.database.Collection(cfg.referralCodeColl)
+	srv.referrals = srv.database.Collection(cfg.referralColl)
+	srv.referralBonusAmount = cfg.referralBonusAmount
+	srv.referralMaxPerUser = cfg.referralMaxPerUser
+	srv.storeReps = srv.database.Collection(cfg.storeRepColl)
+	srv.reviewResponses = srv.database.Collection(cfg.reviewResponseColl)
+	srv.storeCorrections = srv.database.Collection(cfg.storeCorrectionColl)
+	srv.statsHalfLifeDays = cfg.statsHalfLifeDays
+	srv.statsTrimPercent = cfg.statsTrimPercent
+	if rewardCipher, err := newRewardCipher(cfg.rewardLinkKey); err != nil {
+		cfg.serverLog.Printf("報酬リンクの暗号化を初期化できませんでした: %v", err)
+	} else {
+		srv.rewardCipher = rewardCipher
+	}
+	if cfg.smtp.host != "" {
+		if emailSender, err := newEmailNotifier(cfg.smtp); err != nil {
+			cfg.serverLog.Printf("メール通知を無効化します: %v", err)
+		} else {
+			srv.emailNotifier = emailSender
+		}
+	}
 	return srv
 }
 
@@ -851,6 +1420,9 @@ func (s *server) storeListHandler() http.HandlerFunc {
 		if categoryFilter != "" {
 			filter["industryCodes"] = categoryFilter
 		}
+		if query.Get("includeClosed") != "true" {
+			filter["status"] = bson.M{"$ne": storeStatusClosed}
+		}
 
 		cursor, err := s.stores.Find(ctx, filter)
 		if err != nil {
@@ -903,6 +1475,9 @@ func (s *server) storeListHandler() http.HandlerFunc {
 				WaitTimeHours:       waitHours,
 				WaitTimeLabel:       waitLabel,
 				ReviewCount:         store.Stats.ReviewCount,
+				Status:              storeStatus(store),
+				ClosedOn:            storeClosedOn(store, s.location),
+				Statistics:          buildStoreStatistics(store),
 			}
 			summaries = append(summaries, summary)
 		}
@@ -967,6 +1542,10 @@ func (s *server) writeJSON(w http.ResponseWriter, status int, payload any) {
 }
 
 func (s *server) shutdown(ctx context.Context) {
+	if s.stopBackground != nil {
+		s.stopBackground()
+		s.releaseSchedulerLease()
+	}
 	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	if err := s.client.Disconnect(shutdownCtx); err != nil {
@@ -1015,9 +1594,10 @@ type reviewSummaryResponse struct {
 
 type reviewDetailResponse struct {
 	reviewSummaryResponse
-	Description       string `json:"description"`
-	AuthorDisplayName string `json:"authorDisplayName"`
-	AuthorAvatarURL   string `json:"authorAvatarUrl,omitempty"`
+	Description       string            `json:"description"`
+	AuthorDisplayName string            `json:"authorDisplayName"`
+	AuthorAvatarURL   string            `json:"authorAvatarUrl,omitempty"`
+	OfficialResponse  *officialResponse `json:"officialResponse,omitempty"`
 }
 
 type reviewListResponse struct {
@@ -1028,31 +1608,46 @@ type reviewListResponse struct {
 }
 
 type adminReviewResponse struct {
-	ID             string     `json:"id"`
-	StoreID        string     `json:"storeId"`
-	StoreName      string     `json:"storeName"`
-	BranchName     string     `json:"branchName,omitempty"`
-	Prefecture     string     `json:"prefecture"`
-	Category       string     `json:"category"`
-	VisitedAt      string     `json:"visitedAt"`
-	Age            int        `json:"age"`
-	SpecScore      int        `json:"specScore"`
-	WaitTimeHours  int        `json:"waitTimeHours"`
-	AverageEarning int        `json:"averageEarning"`
-	Rating         float64    `json:"rating"`
-	Status         string     `json:"status"`
-	StatusNote     string     `json:"statusNote,omitempty"`
-	ReviewedBy     string     `json:"reviewedBy,omitempty"`
-	ReviewedAt     *time.Time `json:"reviewedAt,omitempty"`
-	Comment        string     `json:"comment,omitempty"`
-	RewardStatus   string     `json:"rewardStatus"`
-	RewardNote     string     `json:"rewardNote,omitempty"`
-	RewardSentAt   *time.Time `json:"rewardSentAt,omitempty"`
-	ReviewerID     string     `json:"reviewerId,omitempty"`
-	ReviewerName   string     `json:"reviewerName,omitempty"`
-	ReviewerHandle string     `json:"reviewerHandle,omitempty"`
-	CreatedAt      time.Time  `json:"createdAt"`
-	UpdatedAt      time.Time  `json:"updatedAt"`
+	ID             string                  `json:"id"`
+	StoreID        string                  `json:"storeId"`
+	StoreName      string                  `json:"storeName"`
+	BranchName     string                  `json:"branchName,omitempty"`
+	Prefecture     string                  `json:"prefecture"`
+	Category       string                  `json:"category"`
+	VisitedAt      string                  `json:"visitedAt"`
+	Age            int                     `json:"age"`
+	SpecScore      int                     `json:"specScore"`
+	WaitTimeHours  int                     `json:"waitTimeHours"`
+	AverageEarning int                     `json:"averageEarning"`
+	Rating         float64                 `json:"rating"`
+	Status         string                  `json:"status"`
+	StatusNote     string                  `json:"statusNote,omitempty"`
+	ReviewedBy     string                  `json:"reviewedBy,omitempty"`
+	ReviewedAt     *time.Time              `json:"reviewedAt,omitempty"`
+	Comment        string                  `json:"comment,omitempty"`
+	RewardStatus   string                  `json:"rewardStatus"`
+	RewardNote     string                  `json:"rewardNote,omitempty"`
+	RewardSentAt   *time.Time              `json:"rewardSentAt,omitempty"`
+	RewardAmount   int                     `json:"rewardAmount,omitempty"`
+	RewardHold     string                  `json:"rewardHoldReason,omitempty"`
+	RewardMaker    string                  `json:"rewardRequestedBy,omitempty"`
+	RewardChecker  string                  `json:"rewardConfirmedBy,omitempty"`
+	Feedback       *reviewFeedbackResponse `json:"feedback,omitempty"`
+	Revision       int                     `json:"revision"`
+	PublishedRev   int                     `json:"publishedRevision,omitempty"`
+	Warnings       []reviewWarningResponse `json:"warnings,omitempty"`
+	Anomaly        *reviewAnomalyResponse  `json:"anomaly,omitempty"`
+	Moderation     *moderationResponse     `json:"moderation,omitempty"`
+	Findings       []contentFinding        `json:"contentFindings,omitempty"`
+	DeletedAt      *time.Time              `json:"deletedAt,omitempty"`
+	DeletedBy      string                  `json:"deletedBy,omitempty"`
+	DeleteReason   string                  `json:"deleteReason,omitempty"`
+	CampaignCode   string                  `json:"campaign,omitempty"`
+	ReviewerID     string                  `json:"reviewerId,omitempty"`
+	ReviewerName   string                  `json:"reviewerName,omitempty"`
+	ReviewerHandle string                  `json:"reviewerHandle,omitempty"`
+	CreatedAt      time.Time               `json:"createdAt"`
+	UpdatedAt      time.Time               `json:"updatedAt"`
 }
 
 type adminReviewListResponse struct {
@@ -1060,13 +1655,18 @@ type adminReviewListResponse struct {
 }
 
 type adminStoreResponse struct {
-	ID             string     `json:"id"`
-	Name           string     `json:"name"`
-	BranchName     string     `json:"branchName,omitempty"`
-	Prefecture     string     `json:"prefecture,omitempty"`
-	IndustryCodes  []string   `json:"industryCodes,omitempty"`
-	ReviewCount    int        `json:"reviewCount"`
-	LastReviewedAt *time.Time `json:"lastReviewedAt,omitempty"`
+	ID             string                   `json:"id"`
+	Name           string                   `json:"name"`
+	BranchName     string                   `json:"branchName,omitempty"`
+	Prefecture     string                   `json:"prefecture,omitempty"`
+	IndustryCodes  []string                 `json:"industryCodes,omitempty"`
+	Status         string                   `json:"status"`
+	ClosedAt       *time.Time               `json:"closedAt,omitempty"`
+	StatusNote     string                   `json:"statusNote,omitempty"`
+	PreviousNames  []string                 `json:"previousNames,omitempty"`
+	Statistics     *storeStatisticsResponse `json:"statistics,omitempty"`
+	ReviewCount    int                      `json:"reviewCount"`
+	LastReviewedAt *time.Time               `json:"lastReviewedAt,omitempty"`
 }
 
 type createReviewRequest struct {
@@ -1081,6 +1681,8 @@ type createReviewRequest struct {
 	AverageEarning int     `json:"averageEarning"`
 	Comment        string  `json:"comment"`
 	Rating         float64 `json:"rating"`
+	ReferralCode   string  `json:"referralCode"`
+	clamped        []string
 }
 
 type createReviewResponse struct {
@@ -1106,24 +1708,28 @@ func (req *createReviewRequest) validate() error {
 	}
 	if req.Age > 60 {
 		req.Age = 60
+		req.clamped = append(req.clamped, "age")
 	}
 	if req.SpecScore < 60 {
 		return errors.New("スペックは60以上で入力してください")
 	}
 	if req.SpecScore > 140 {
 		req.SpecScore = 140
+		req.clamped = append(req.clamped, "specScore")
 	}
 	if req.WaitTimeHours < 1 {
 		return errors.New("待機時間は1時間以上で入力してください")
 	}
 	if req.WaitTimeHours > 24 {
 		req.WaitTimeHours = 24
+		req.clamped = append(req.clamped, "waitTimeHours")
 	}
 	if req.AverageEarning < 0 {
 		return errors.New("平均稼ぎは0以上で入力してください")
 	}
 	if req.AverageEarning > 20 {
 		req.AverageEarning = 20
+		req.clamped = append(req.clamped, "averageEarning")
 	}
 	if req.Rating < 0 || req.Rating > 5 {
 		return errors.New("総評は0〜5の範囲で入力してください")
@@ -1171,16 +1777,19 @@ func formatVisitedDisplay(visited string) string {
 }
 
 type storeSummaryResponse struct {
-	ID                  string  `json:"id"`
-	StoreName           string  `json:"storeName"`
-	Prefecture          string  `json:"prefecture"`
-	Category            string  `json:"category"`
-	AverageRating       float64 `json:"averageRating"`
-	AverageEarning      int     `json:"averageEarning"`
-	AverageEarningLabel string  `json:"averageEarningLabel,omitempty"`
-	WaitTimeHours       int     `json:"waitTimeHours"`
-	WaitTimeLabel       string  `json:"waitTimeLabel,omitempty"`
-	ReviewCount         int     `json:"reviewCount"`
+	ID                  string                   `json:"id"`
+	StoreName           string                   `json:"storeName"`
+	Prefecture          string                   `json:"prefecture"`
+	Category            string                   `json:"category"`
+	AverageRating       float64                  `json:"averageRating"`
+	AverageEarning      int                      `json:"averageEarning"`
+	AverageEarningLabel string                   `json:"averageEarningLabel,omitempty"`
+	WaitTimeHours       int                      `json:"waitTimeHours"`
+	WaitTimeLabel       string                   `json:"waitTimeLabel,omitempty"`
+	ReviewCount         int                      `json:"reviewCount"`
+	Status              string                   `json:"status"`
+	ClosedOn            string                   `json:"closedOn,omitempty"`
+	Statistics          *storeStatisticsResponse `json:"statistics,omitempty"`
 }
 
 type storeListResponse struct {
@@ -1209,6 +1818,14 @@ func parsePositiveInt(value string, fallback int) (int, bool) {
 	return num, true
 }
 
+func parseNonNegativeInt(value string, fallback int) (int, bool) {
+	num, ok := parseInt(value)
+	if !ok || num < 0 {
+		return fallback, false
+	}
+	return num, true
+}
+
 var numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
 
 func parseFirstNumber(input string) (float64, bool) {
@@ -1223,20 +1840,26 @@ func parseFirstNumber(input string) (float64, bool) {
 	return value, true
 }
 
-func (s *server) notifyReviewReceipt(ctx context.Context, user authenticatedUser, summary reviewSummaryResponse, comment string) {
+func (s *server) notifyReviewReceipt(ctx context.Context, user authenticatedUser, summary reviewSummaryResponse, comment string, rewardAmount int) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if userID := strings.TrimSpace(user.ID); userID != "" {
-		message := buildReceiptMessage(summary, comment)
-		if err := s.sendLineMessage(ctx, userID, message); err != nil && s.logger != nil {
-			s.logger.Printf("LINE通知の送信に失敗: %v", err)
-		}
+	s.notifyReviewer(ctx, user.ID, notification{
+		Subject:        "アンケートを受け付けました",
+		Text:           buildReceiptMessage(summary, comment, rewardAmount),
+		IdempotencyKey: "review-receipt:" + summary.ID,
+	})
+}
+
+func (s *server) notifyModerators(ctx context.Context, user authenticatedUser, summary reviewSummaryResponse, comment string, revision int) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	if strings.TrimSpace(s.discordDestination) != "" {
-		discordMessage := buildDiscordReviewMessage(s.adminReviewBaseURL, user, summary, comment)
+	if s.discordNotifier != nil {
+		resubmitted := revision > 1
+		discordMessage := buildDiscordReviewMessage(s.adminReviewBaseURL, user, summary, comment, resubmitted)
 		if discordMessage != "" {
 			identifier := summary.ID
 			if identifier == "" {
@@ -1248,7 +1871,16 @@ func (s *server) notifyReviewReceipt(ctx context.Context, user authenticatedUser
 			if identifier == "" {
 				identifier = "discord"
 			}
-			if err := s.sendDiscordMessage(ctx, identifier, discordMessage); err != nil && s.logger != nil {
+			message := notification{
+				Recipient:      identifier,
+				Text:           discordMessage,
+				IdempotencyKey: "review-created:" + summary.ID,
+				Review:         &reviewNotification{User: user, Summary: summary, Comment: comment, Resubmitted: resubmitted},
+			}
+			if resubmitted {
+				message.IdempotencyKey = fmt.Sprintf("review-resubmitted:%s:%d", summary.ID, revision)
+			}
+			if err := s.discordNotifier.send(ctx, message); err != nil && s.logger != nil {
 				s.logger.Printf("Discord通知の送信に失敗: %v", err)
 			}
 		}
@@ -1263,7 +1895,7 @@ func reviewerDisplayName(user authenticatedUser) string {
 	return "匿名店舗アンケート"
 }
 
-func buildReceiptMessage(summary reviewSummaryResponse, comment string) string {
+func buildReceiptMessage(summary reviewSummaryResponse, comment string, rewardAmount int) string {
 	sections := [][]string{}
 
 	addSection := func(title, value string) {
@@ -1308,11 +1940,41 @@ func buildReceiptMessage(summary reviewSummaryResponse, comment string) string {
 		lines = append(lines, section...)
 		lines = append(lines, "")
 	}
-	lines = append(lines, "内容の確認が終わり次第PayPay1000円分のリンクをお送りします。")
+	lines = append(lines, fmt.Sprintf("内容の確認が終わり次第PayPay%d円分のリンクをお送りします。", rewardAmount))
 
 	return strings.Join(lines, "\n")
 }
 
+func (s *server) notifyReviewApproved(ctx context.Context, review reviewDocument, store storeDocument) {
+	summary := s.buildReviewSummary(review, store)
+	s.notifyReviewer(ctx, review.ReviewerID, notification{
+		Subject:        "アンケートが承認されました",
+		Text:           buildApprovalMessage(summary, review.Reward),
+		IdempotencyKey: "review-approved:" + summary.ID,
+	})
+}
+
+func buildApprovalMessage(summary reviewSummaryResponse, reward reviewRewardDocument) string {
+	lines := []string{
+		"アンケートが承認されました。ご協力ありがとうございます！",
+		"",
+	}
+	for _, field := range reviewNotificationFields(summary, "") {
+		if field.Title != "店舗名" && field.Title != "支店名" && field.Title != "訪問時期" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("**%s**", field.Title), "> "+field.Value, "")
+	}
+	if strings.TrimSpace(reward.Status) == rewardStatusHeld {
+		lines = append(lines, "謝礼のお支払いについては確認のうえ改めてご連絡します。")
+	} else if reward.Amount > 0 {
+		lines = append(lines, fmt.Sprintf("PayPay%d円分のリンクは準備ができ次第お送りします。", reward.Amount))
+	} else {
+		lines = append(lines, "PayPayのリンクは準備ができ次第お送りします。")
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatDiscordTimestamp(value string) string {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -1345,46 +2007,21 @@ func formatRatingValue(value float64) string {
 	return formatted
 }
 
-func buildDiscordReviewMessage(adminBaseURL string, user authenticatedUser, summary reviewSummaryResponse, comment string) string {
+func buildDiscordReviewMessage(adminBaseURL string, user authenticatedUser, summary reviewSummaryResponse, comment string, resubmitted bool) string {
 	sections := [][]string{}
-
-	addSection := func(title, value string) {
-		value = strings.TrimSpace(value)
-		if value == "" {
-			return
-		}
+	for _, field := range reviewNotificationFields(summary, comment) {
 		sections = append(sections, []string{
-			fmt.Sprintf("**%s**", title),
-			"> " + value,
+			fmt.Sprintf("**%s**", field.Title),
+			"> " + field.Value,
 		})
 	}
 
-	addSection("店舗名", summary.StoreName)
-	addSection("支店名", summary.BranchName)
-	addSection("都道府県", summary.Prefecture)
-	addSection("訪問時期", formatVisitedDisplay(summary.VisitedAt))
-	if summary.AverageEarning > 0 {
-		addSection("平均稼ぎ", fmt.Sprintf("%d万円", summary.AverageEarning))
-	}
-	if summary.WaitTimeHours > 0 {
-		addSection("待機時間", fmt.Sprintf("%d時間", summary.WaitTimeHours))
-	}
-	if summary.Age > 0 {
-		addSection("年齢", fmt.Sprintf("%d歳", summary.Age))
-	}
-	if summary.SpecScore > 0 {
-		addSection("スペック", fmt.Sprintf("%d", summary.SpecScore))
-	}
-	if trimmed := strings.TrimSpace(comment); trimmed != "" {
-		addSection("客層・スタッフ・環境等", trimmed)
-	}
-	if summary.Rating > 0 {
-		addSection("満足度", formatRatingValue(summary.Rating))
-	}
-
 	lines := []string{
 		"📝 **アンケートが投稿されました**",
 	}
+	if resubmitted {
+		lines[0] = "🔁 **修正依頼中のアンケートが再提出されました**"
+	}
 
 	if postedAt := formatDiscordTimestamp(summary.CreatedAt); postedAt != "" {
 		lines = append(lines, fmt.Sprintf("🕐 投稿日時: %s", postedAt))
@@ -1403,11 +2040,7 @@ func buildDiscordReviewMessage(adminBaseURL string, user authenticatedUser, summ
 		lines = append(lines, "")
 	}
 
-	if trimmed := strings.TrimSpace(adminBaseURL); trimmed != "" {
-		link := strings.TrimSuffix(trimmed, "/")
-		if summary.ID != "" {
-			link = link + "/" + summary.ID
-		}
+	if link := adminReviewLink(adminBaseURL, summary.ID); link != "" {
 		lines = append(lines, fmt.Sprintf("🔗 [管理画面](%s)", link))
 	}
 
@@ -1416,7 +2049,7 @@ func buildDiscordReviewMessage(adminBaseURL string, user authenticatedUser, summ
 	return strings.Join(lines, "\n")
 }
 
-func (s *server) sendMessengerMessage(ctx context.Context, destination, userID, text string) error {
+func (s *server) sendMessengerMessage(ctx context.Context, destination, userID, text, idempotencyKey string) error {
 	if s.httpClient == nil || s.messengerEndpoint == "" {
 		return errors.New("メッセンジャー送信の設定がされていません")
 	}
@@ -1461,13 +2094,28 @@ func (s *server) sendMessengerMessage(ctx context.Context, destination, userID,
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if key := strings.TrimSpace(idempotencyKey); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	} else {
+		req.Header.Set("Idempotency-Key", primitive.NewObjectID().Hex())
+	}
+	s.signMessengerRequest(req, body)
+
+	if s.messengerBreaker != nil {
+		if err := s.messengerBreaker.allow(); err != nil {
+			return fmt.Errorf("メッセンジャー送信をスキップ: %w", err)
+		}
+	}
 
 	res, err := s.httpClient.Do(req)
 	if err != nil {
+		s.recordMessengerResult(false)
 		return fmt.Errorf("メッセンジャー送信リクエストに失敗: %w", err)
 	}
 	defer res.Body.Close()
 
+	s.recordMessengerResult(res.StatusCode < 500)
+
 	if res.StatusCode >= 400 {
 		message, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
 		return fmt.Errorf("メッセンジャー送信でエラーが発生: status=%d body=%s", res.StatusCode, strings.TrimSpace(string(message)))
@@ -1476,16 +2124,30 @@ func (s *server) sendMessengerMessage(ctx context.Context, destination, userID,
 	return nil
 }
 
-func (s *server) sendLineMessage(ctx context.Context, userID, text string) error {
-	return s.sendMessengerMessage(ctx, s.messengerDestination, userID, text)
+func (s *server) recordMessengerResult(success bool) {
+	if s.messengerBreaker != nil {
+		s.messengerBreaker.record(success)
+	}
 }
 
-func (s *server) sendDiscordMessage(ctx context.Context, userID, text string) error {
-	dest := strings.TrimSpace(s.discordDestination)
-	if dest == "" {
-		return nil
+func (s *server) signMessengerRequest(req *http.Request, body []byte) {
+	if token := s.messengerToken; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
-	return s.sendMessengerMessage(ctx, dest, userID, text)
+	if s.messengerSecret == "" {
+		return
+	}
+
+	digest := sha256.Sum256(body)
+	bodyDigest := hex.EncodeToString(digest[:])
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(s.messengerSecret))
+	mac.Write([]byte(strings.Join([]string{timestamp, req.Method, req.URL.RequestURI(), req.Header.Get("Idempotency-Key"), bodyDigest}, "\n")))
+
+	req.Header.Set("X-Makoto-Timestamp", timestamp)
+	req.Header.Set("X-Makoto-Content-SHA256", bodyDigest)
+	req.Header.Set("X-Makoto-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
 }
 
 func (s *server) reviewCreateHandler() http.HandlerFunc {
@@ -1530,12 +2192,38 @@ func (s *server) reviewCreateHandler() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
-		store, err := s.findOrCreateStore(ctx, storeName, branchName, prefecture, category)
+		referral, err := s.prepareReferral(ctx, user, req.ReferralCode)
+		if err != nil {
+			if isReferralRejection(err) {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			s.logger.Printf("紹介コードの確認に失敗: %v", err)
+			http.Error(w, "レビューの保存に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		store, storeCreated, err := s.findOrCreateStore(ctx, storeName, branchName, prefecture, category)
 		if err != nil {
 			s.logger.Printf("店舗の取得/作成に失敗: %v", err)
 			http.Error(w, "店舗情報の処理に失敗しました", http.StatusInternalServerError)
 			return
 		}
+		if err := checkStoreAcceptsVisit(store, req.VisitedAt, s.location); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		if err := s.ensureNotDuplicateReview(ctx, user.ID, store.ID, period, primitive.NilObjectID); err != nil {
+			if errors.Is(err, errDuplicateReview) {
+				s.logger.Printf("重複アンケートを拒否 userId=%s storeId=%s period=%s", user.ID, store.ID.Hex(), period)
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+				return
+			}
+			s.logger.Printf("重複アンケートの確認に失敗: %v", err)
+			http.Error(w, "レビューの保存に失敗しました", http.StatusInternalServerError)
+			return
+		}
 
 		reviewID := primitive.NewObjectID()
 		reviewDoc := reviewDocument{
@@ -1558,12 +2246,48 @@ func (s *server) reviewCreateHandler() http.HandlerFunc {
 			CreatedAt:        now,
 			UpdatedAt:        now,
 		}
+		reviewDoc.Campaign = s.matchReviewCampaign(ctx, reviewDoc, store)
+
+		flags, err := s.detectReviewFlags(ctx, reviewDoc)
+		if err != nil {
+			s.logger.Printf("重複アンケートの検知に失敗: %v", err)
+		}
+		reviewDoc.ContentFindings = s.scanReviewContent(ctx, comment)
+		flags = append(flags, contentFlags(reviewDoc.ContentFindings, now)...)
+		if anomaly, err := s.scoreReviewAnomaly(ctx, reviewDoc, store, req.clamped); err != nil {
+			s.logger.Printf("外れ値スコアの算出に失敗: %v", err)
+		} else {
+			reviewDoc.Anomaly = anomaly
+		}
+		if len(flags) > 0 {
+			reviewDoc.Flags = flags
+			s.logger.Printf("アンケートに警告フラグを付与 id=%s flags=%d", reviewID.Hex(), len(flags))
+		}
 
 		if _, err := s.reviews.InsertOne(ctx, reviewDoc); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				s.logger.Printf("重複アンケートを拒否 userId=%s storeId=%s period=%s", user.ID, store.ID.Hex(), period)
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": errDuplicateReview.Error()})
+				return
+			}
 			s.logger.Printf("レビューの保存に失敗: %v", err)
 			http.Error(w, "レビューの保存に失敗しました", http.StatusInternalServerError)
 			return
 		}
+		if storeCreated {
+			s.emitWebhookEvent(webhookEventStoreCreated, storeDocumentToAdminResponse(store))
+		}
+		s.indexReviewComment(ctx, reviewDoc)
+		s.saveReferral(ctx, referral, reviewID)
+
+		received := s.buildReviewSummary(reviewDoc, store)
+		s.emitWebhookEvent(webhookEventReviewCreated, buildAdminReviewResponse(reviewDoc, store))
+		go s.notifyReviewReceipt(context.Background(), user, received, comment, s.expectedRewardAmount(ctx, reviewDoc))
+
+		reviewDoc = s.applyModerationRules(ctx, reviewDoc, store)
+		if reviewAwaitsModerator(reviewDoc) {
+			go s.notifyModerators(context.Background(), user, received, comment, 1)
+		}
 
 		if category != "" {
 			_, err := s.stores.UpdateByID(ctx, store.ID, bson.M{"$addToSet": bson.M{"industryCodes": category}})
@@ -1584,8 +2308,6 @@ func (s *server) reviewCreateHandler() http.HandlerFunc {
 		detail.AuthorDisplayName = reviewerDisplayName(user)
 		detail.AuthorAvatarURL = user.Picture
 
-		go s.notifyReviewReceipt(context.Background(), user, summary, comment)
-
 		s.writeJSON(w, http.StatusCreated, createReviewResponse{
 			Status: "ok",
 			Review: summary,
@@ -1595,19 +2317,18 @@ func (s *server) reviewCreateHandler() http.HandlerFunc {
 }
 
 type reviewQueryParams struct {
-	Prefecture  string
-	Category    string
-	CategoryRaw string
-	StoreName   string
-	Sort        string
-	Page        int
-	Limit       int
+	Prefecture    string
+	Category      string
+	CategoryRaw   string
+	StoreName     string
+	Sort          string
+	Page          int
+	Limit         int
+	IncludeClosed bool
 }
 
 func (s *server) collectReviews(ctx context.Context, params reviewQueryParams) ([]reviewSummaryResponse, error) {
-	filter := bson.M{
-		"status": "approved",
-	}
+	filter := publicReviewFilter(bson.M{})
 	if params.Category != "" {
 		categories := []string{params.Category}
 		raw := strings.TrimSpace(params.CategoryRaw)
@@ -1663,7 +2384,7 @@ func (s *server) collectReviews(ctx context.Context, params reviewQueryParams) (
 	summaries := make([]reviewSummaryResponse, 0, len(reviews))
 	for _, review := range reviews {
 		store, ok := storeMap[review.StoreID]
-		if !ok {
+		if !ok || (isStoreClosed(store) && !params.IncludeClosed) {
 			continue
 		}
 		summaries = append(summaries, s.buildReviewSummary(review, store))
@@ -1698,7 +2419,7 @@ func (s *server) buildReviewSummary(review reviewDocument, store storeDocument)
 		earningLabel = formatAverageEarningLabel(earning)
 	}
 
-	excerpt := buildExcerpt(review.Comment, store.Name, earningLabel, waitLabel)
+	excerpt := buildExcerpt(s.publicComment(review), store.Name, earningLabel, waitLabel)
 
 	return reviewSummaryResponse{
 		ID:             review.ID.Hex(),
@@ -1721,7 +2442,7 @@ func (s *server) buildReviewSummary(review reviewDocument, store storeDocument)
 
 func (s *server) buildReviewDetail(review reviewDocument, store storeDocument) reviewDetailResponse {
 	summary := s.buildReviewSummary(review, store)
-	description := strings.TrimSpace(review.Comment)
+	description := strings.TrimSpace(s.publicComment(review))
 	if description == "" {
 		description = buildFallbackDescription(summary)
 	}
@@ -1796,6 +2517,21 @@ func buildAdminReviewResponse(review reviewDocument, store storeDocument) adminR
 		RewardStatus:   rewardStatus,
 		RewardNote:     strings.TrimSpace(review.Reward.Note),
 		RewardSentAt:   review.Reward.SentAt,
+		RewardAmount:   review.Reward.Amount,
+		RewardHold:     review.Reward.HoldReason,
+		RewardMaker:    review.Reward.RequestedBy,
+		RewardChecker:  review.Reward.ConfirmedBy,
+		Feedback:       feedbackToResponse(review.Feedback),
+		Revision:       currentRevision(review),
+		PublishedRev:   review.PublishedRev,
+		Warnings:       buildReviewWarnings(review.Flags),
+		Anomaly:        buildAnomalyResponse(review.Anomaly),
+		Moderation:     buildModerationResponse(review.Moderation),
+		Findings:       buildContentFindings(review.ContentFindings),
+		DeletedAt:      review.DeletedAt,
+		DeletedBy:      review.DeletedBy,
+		DeleteReason:   review.DeleteReason,
+		CampaignCode:   reviewCampaignCode(review),
 		ReviewerID:     strings.TrimSpace(review.ReviewerID),
 		ReviewerName:   strings.TrimSpace(review.ReviewerName),
 		ReviewerHandle: strings.TrimSpace(review.ReviewerUsername),
@@ -1813,11 +2549,13 @@ func deriveHelpfulCount(id primitive.ObjectID, spec int) int {
 }
 
 type updateReviewStatusRequest struct {
-	Status       string `json:"status"`
-	StatusNote   string `json:"statusNote"`
-	ReviewedBy   string `json:"reviewedBy"`
-	RewardStatus string `json:"rewardStatus"`
-	RewardNote   string `json:"rewardNote"`
+	Status          string   `json:"status"`
+	StatusNote      string   `json:"statusNote"`
+	ReviewedBy      string   `json:"reviewedBy"`
+	RewardStatus    string   `json:"rewardStatus"`
+	RewardNote      string   `json:"rewardNote"`
+	FeedbackReasons []string `json:"feedbackReasons"`
+	FeedbackMessage string   `json:"feedbackMessage"`
 }
 
 type updateReviewContentRequest struct {
@@ -1833,6 +2571,7 @@ type updateReviewContentRequest struct {
 	AverageEarning *int     `json:"averageEarning"`
 	Comment        *string  `json:"comment"`
 	Rating         *float64 `json:"rating"`
+	EditedBy       string   `json:"editedBy"`
 }
 
 func (s *server) adminReviewListHandler() http.HandlerFunc {
@@ -1842,6 +2581,25 @@ func (s *server) adminReviewListHandler() http.HandlerFunc {
 		if status != "" && status != "all" {
 			filter["status"] = status
 		}
+		switch flag := strings.TrimSpace(r.URL.Query().Get("flag")); flag {
+		case "":
+		case "any":
+			filter["flags.0"] = bson.M{"$exists": true}
+		case "none":
+			filter["flags.0"] = bson.M{"$exists": false}
+		default:
+			filter["flags.code"] = flag
+		}
+		if r.URL.Query().Get("outlier") == "true" {
+			filter["anomaly.outlier"] = true
+		}
+		switch r.URL.Query().Get("deleted") {
+		case "include":
+		case "only":
+			filter["deletedAt"] = bson.M{"$exists": true}
+		default:
+			filter["deletedAt"] = bson.M{"$exists": false}
+		}
 
 		opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
 
@@ -2100,28 +2858,18 @@ func (s *server) adminReviewUpdateHandler() http.HandlerFunc {
 			return
 		}
 
-		if len(storeUpdate) > 0 && !targetStoreID.IsZero() {
-			storeUpdate["updatedAt"] = now
-			if _, err := s.stores.UpdateByID(ctx, targetStoreID, bson.M{"$set": storeUpdate}); err != nil {
-				s.logger.Printf("admin review content update store update failed id=%q storeId=%s err=%v", idParam, targetStoreID.Hex(), err)
-				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の更新に失敗しました"})
-				return
-			}
-		}
-		if addIndustry != "" && !targetStoreID.IsZero() {
-			if _, err := s.stores.UpdateByID(ctx, targetStoreID, bson.M{"$addToSet": bson.M{"industryCodes": addIndustry}}); err != nil {
-				s.logger.Printf("admin review content update industry append failed id=%q storeId=%s err=%v", idParam, targetStoreID.Hex(), err)
-			}
+		if err := s.applyStoreUpdate(ctx, targetStoreID, storeUpdate, addIndustry, now); err != nil {
+			s.logger.Printf("admin review content update store update failed id=%q storeId=%s err=%v", idParam, targetStoreID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の更新に失敗しました"})
+			return
 		}
 
 		var updated reviewDocument
 		if len(reviewUpdate) > 0 {
-			reviewUpdate["updatedAt"] = now
-			result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": reviewUpdate}, options.FindOneAndUpdate().SetReturnDocument(options.After))
-			if err := result.Decode(&updated); err != nil {
-				if errors.Is(err, mongo.ErrNoDocuments) {
-					s.logger.Printf("admin review content update disappeared id=%q", idParam)
-					s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+			updated, err = s.updateReviewContentAsAdmin(ctx, existing, reviewUpdate, strings.TrimSpace(req.EditedBy), "edit", 0)
+			if err != nil {
+				if errors.Is(err, errRevisionConflict) {
+					s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 					return
 				}
 				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
@@ -2168,6 +2916,7 @@ func (s *server) adminReviewStatusHandler() http.HandlerFunc {
 			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
 			return
 		}
+		req.ReviewedBy = adminActor(r, req.ReviewedBy)
 
 		update := bson.M{}
 		now := time.Now().In(s.location)
@@ -2175,18 +2924,73 @@ func (s *server) adminReviewStatusHandler() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
+		var existing reviewDocument
+		if err := s.reviews.FindOne(ctx, bson.M{"_id": objectID}).Decode(&existing); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.logger.Printf("admin review status update not found id=%q", idParam)
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+			return
+		}
+		if existing.DeletedAt != nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "削除済みのレビューは復元してから更新してください"})
+			return
+		}
+
+		if strings.TrimSpace(req.Status) == "approved" {
+			pending, handled, ok := s.recordModeratorApproval(ctx, w, existing, req.ReviewedBy)
+			if !ok {
+				return
+			}
+			if handled {
+				store, err := s.getStoreByID(ctx, pending.StoreID)
+				if err != nil {
+					s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+					return
+				}
+				s.logger.Printf("admin review first approval recorded id=%q by=%q", idParam, strings.TrimSpace(req.ReviewedBy))
+				s.writeJSON(w, http.StatusAccepted, buildAdminReviewResponse(pending, store))
+				return
+			}
+			if pending.Moderation != nil && len(pending.Moderation.Approvals) > 0 {
+				update["moderation.approvals"] = pending.Moderation.Approvals
+			}
+		}
+
 		if status := strings.TrimSpace(req.Status); status != "" {
 			update["status"] = status
 			update["statusNote"] = strings.TrimSpace(req.StatusNote)
 			update["reviewedBy"] = strings.TrimSpace(req.ReviewedBy)
-			if status == "approved" || status == "rejected" {
+			if status == "approved" || status == "rejected" || status == reviewStatusNeedsChanges {
 				update["reviewedAt"] = now
 			} else {
 				update["reviewedAt"] = nil
 			}
+			if status == "approved" {
+				update["publishedRevision"] = currentRevision(existing)
+			}
+			if status == reviewStatusNeedsChanges {
+				feedback, err := buildReviewFeedback(req.FeedbackReasons, req.FeedbackMessage, req.ReviewedBy, now)
+				if err != nil {
+					s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+					return
+				}
+				update["feedback"] = feedback
+			}
 		}
 
 		if reward := strings.TrimSpace(req.RewardStatus); reward != "" {
+			previous := strings.TrimSpace(existing.Reward.Status)
+			if previous == rewardStatusAwaiting && reward != rewardStatusAwaiting {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "確認待ちの謝礼は確認APIから承認または差し戻してください"})
+				return
+			}
+			if reward == rewardStatusSent && previous != rewardStatusSent && !s.rewardConfirmed(existing) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": errRewardUnconfirmed.Error()})
+				return
+			}
 			update["reward.status"] = reward
 			update["reward.note"] = strings.TrimSpace(req.RewardNote)
 			if reward == "sent" {
@@ -2196,6 +3000,15 @@ func (s *server) adminReviewStatusHandler() http.HandlerFunc {
 			}
 		}
 
+		committing := rewardAboutToIssue(existing, strings.TrimSpace(req.Status), strings.TrimSpace(req.RewardStatus))
+		if committing {
+			if _, err := s.applyRewardDecision(ctx, existing, update, strings.TrimSpace(req.ReviewedBy), now); err != nil {
+				s.logger.Printf("admin review status update reward policy failed id=%q err=%v", idParam, err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの評価に失敗しました"})
+				return
+			}
+		}
+
 		if len(update) == 0 {
 			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "更新内容が指定されていません"})
 			return
@@ -2211,9 +3024,16 @@ func (s *server) adminReviewStatusHandler() http.HandlerFunc {
 				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
 				return
 			}
+			if mongo.IsDuplicateKeyError(err) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": errDuplicateReview.Error()})
+				return
+			}
 			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
 			return
 		}
+		if committing {
+			updated = s.recheckRewardCommit(ctx, updated)
+		}
 
 		if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
 			s.logger.Printf("admin review status update stats recalculation failed id=%q err=%v", idParam, err)
@@ -2228,10 +3048,40 @@ func (s *server) adminReviewStatusHandler() http.HandlerFunc {
 
 		s.logger.Printf("admin review status update success id=%q status=%q rewardStatus=%q", idParam, strings.TrimSpace(updated.Status), strings.TrimSpace(updated.Reward.Status))
 
+		s.handleReviewStatusTransition(ctx, existing, updated, store)
 		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
 	}
 }
 
+func (s *server) handleReviewStatusTransition(ctx context.Context, existing, updated reviewDocument, store storeDocument) {
+	response := buildAdminReviewResponse(updated, store)
+	if previous, current := strings.TrimSpace(existing.Status), strings.TrimSpace(updated.Status); previous != current {
+		switch current {
+		case "approved":
+			go s.notifyReviewApproved(context.Background(), updated, store)
+			go s.autoIssueReviewReward(updated)
+			s.emitWebhookEvent(webhookEventReviewApproved, response)
+		case "rejected":
+			s.emitWebhookEvent(webhookEventReviewRejected, response)
+		case reviewStatusNeedsChanges:
+			go s.notifyReviewNeedsChanges(context.Background(), updated, store)
+		}
+		if isReviewPublic(existing) && !isReviewPublic(updated) {
+			s.releaseRewardLink(ctx, updated, strings.TrimSpace(updated.ReviewedBy), "status:"+current)
+		}
+		if _, err := s.refreshReviewerProfile(ctx, updated.ReviewerID); err != nil {
+			s.logger.Printf("レビュアープロフィールの更新に失敗 reviewerId=%s err=%v", updated.ReviewerID, err)
+		}
+		s.settleReferral(ctx, updated)
+	}
+	if !isReviewFingerprinted(updated) || !isReviewFingerprinted(existing) {
+		s.indexReviewComment(ctx, updated)
+	}
+	if strings.TrimSpace(existing.Reward.Status) != "sent" && strings.TrimSpace(updated.Reward.Status) == "sent" {
+		s.emitWebhookEvent(webhookEventRewardSent, response)
+	}
+}
+
 func (s *server) adminStoreSearchHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		queryValues := r.URL.Query()
@@ -2362,8 +3212,7 @@ func (s *server) adminStoreCreateHandler() http.HandlerFunc {
 		err := s.stores.FindOne(ctx, filter).Decode(&store)
 		if err != nil {
 			if errors.Is(err, mongo.ErrNoDocuments) {
-				created = true
-				store, err = s.findOrCreateStore(ctx, name, branch, prefecture, industry)
+				store, created, err = s.findOrCreateStore(ctx, name, branch, prefecture, industry)
 				if err != nil {
 					s.logger.Printf("admin store create insert failed: %v", err)
 					s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗の作成に失敗しました"})
@@ -2388,6 +3237,10 @@ func (s *server) adminStoreCreateHandler() http.HandlerFunc {
 			}
 		}
 
+		if created {
+			s.emitWebhookEvent(webhookEventStoreCreated, storeDocumentToAdminResponse(store))
+		}
+
 		response := adminStoreCreateResponse{
 			Store:   storeDocumentToAdminResponse(store),
 			Created: created,
@@ -2404,6 +3257,11 @@ func storeDocumentToAdminResponse(doc storeDocument) adminStoreResponse {
 		BranchName:     strings.TrimSpace(doc.BranchName),
 		Prefecture:     doc.Prefecture,
 		IndustryCodes:  append([]string(nil), doc.IndustryCodes...),
+		Status:         storeStatus(doc),
+		ClosedAt:       doc.ClosedAt,
+		StatusNote:     doc.StatusNote,
+		PreviousNames:  doc.PreviousNames,
+		Statistics:     buildStoreStatistics(doc),
 		ReviewCount:    doc.Stats.ReviewCount,
 		LastReviewedAt: doc.Stats.LastReviewedAt,
 	}
@@ -2527,11 +3385,12 @@ func (s *server) reviewListHandler() http.HandlerFunc {
 		query := r.URL.Query()
 		categoryRaw := strings.TrimSpace(query.Get("category"))
 		params := reviewQueryParams{
-			Prefecture:  strings.TrimSpace(query.Get("prefecture")),
-			Category:    canonicalIndustryCode(categoryRaw),
-			CategoryRaw: categoryRaw,
-			StoreName:   strings.TrimSpace(query.Get("storeName")),
-			Sort:        strings.TrimSpace(query.Get("sort")),
+			Prefecture:    strings.TrimSpace(query.Get("prefecture")),
+			Category:      canonicalIndustryCode(categoryRaw),
+			CategoryRaw:   categoryRaw,
+			StoreName:     strings.TrimSpace(query.Get("storeName")),
+			Sort:          strings.TrimSpace(query.Get("sort")),
+			IncludeClosed: query.Get("includeClosed") == "true",
 		}
 		params.Page, _ = parsePositiveInt(query.Get("page"), 1)
 		params.Limit, _ = parsePositiveInt(query.Get("limit"), 10)
@@ -2626,7 +3485,7 @@ func (s *server) reviewDetailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var review reviewDocument
-	if err := s.reviews.FindOne(ctx, bson.M{"_id": objectID}).Decode(&review); err != nil {
+	if err := s.reviews.FindOne(ctx, publicReviewFilter(bson.M{"_id": objectID})).Decode(&review); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			http.NotFound(w, r)
 			return
@@ -2648,5 +3507,6 @@ func (s *server) reviewDetailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	detail := s.buildReviewDetail(review, store)
+	detail.OfficialResponse = s.loadOfficialResponse(ctx, review.ID)
 	s.writeJSON(w, http.StatusOK, detail)
 }
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditActorReviewer = "reviewer"
+	auditActorAdmin    = "admin"
+
+	reviewStatusWithdrawn = "withdrawn"
+)
+
+type reviewAuditDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	ReviewID  primitive.ObjectID `bson:"reviewId"`
+	Action    string             `bson:"action"`
+	Actor     string             `bson:"actor,omitempty"`
+	ActorRole string             `bson:"actorRole"`
+	Detail    bson.M             `bson:"detail,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+func (s *server) recordReviewAudit(ctx context.Context, reviewID primitive.ObjectID, action, actor, actorRole string, detail bson.M) {
+	doc := reviewAuditDocument{
+		ID:        primitive.NewObjectID(),
+		ReviewID:  reviewID,
+		Action:    action,
+		Actor:     strings.TrimSpace(actor),
+		ActorRole: actorRole,
+		Detail:    detail,
+		CreatedAt: time.Now().In(s.location),
+	}
+	if _, err := s.reviewAudit.InsertOne(ctx, doc); err != nil {
+		s.logger.Printf("レビュー監査ログの保存に失敗 reviewId=%s action=%s err=%v", reviewID.Hex(), action, err)
+	}
+}
+
+type myReviewResponse struct {
+	reviewSummaryResponse
+	Comment       string                  `json:"comment"`
+	Status        string                  `json:"status"`
+	RewardStatus  string                  `json:"rewardStatus"`
+	RewardSentAt  *time.Time              `json:"rewardSentAt,omitempty"`
+	Editable      bool                    `json:"editable"`
+	Resubmittable bool                    `json:"resubmittable"`
+	Feedback      *reviewFeedbackResponse `json:"feedback,omitempty"`
+	WithdrawnAt   *time.Time              `json:"withdrawnAt,omitempty"`
+	UpdatedAt     time.Time               `json:"updatedAt"`
+}
+
+type myReviewListResponse struct {
+	Items []myReviewResponse `json:"items"`
+}
+
+func (s *server) buildMyReviewResponse(review reviewDocument, store storeDocument) myReviewResponse {
+	status := strings.TrimSpace(review.Status)
+	if status == "" {
+		status = "pending"
+	}
+	rewardStatus := strings.TrimSpace(review.Reward.Status)
+	if rewardStatus == "" {
+		rewardStatus = "pending"
+	}
+	return myReviewResponse{
+		reviewSummaryResponse: s.buildReviewSummary(review, store),
+		Comment:               strings.TrimSpace(review.Comment),
+		Status:                status,
+		RewardStatus:          rewardStatus,
+		RewardSentAt:          review.Reward.SentAt,
+		Editable:              status == "pending",
+		Resubmittable:         status == reviewStatusNeedsChanges,
+		Feedback:              feedbackToResponse(review.Feedback),
+		WithdrawnAt:           review.WithdrawnAt,
+		UpdatedAt:             review.UpdatedAt,
+	}
+}
+
+func (s *server) findOwnReview(ctx context.Context, w http.ResponseWriter, r *http.Request) (authenticatedUser, reviewDocument, bool) {
+	user, ok := authenticatedUserFromContext(r.Context())
+	if !ok {
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+		return authenticatedUser{}, reviewDocument{}, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "レビューIDの形式が不正です"})
+		return user, reviewDocument{}, false
+	}
+
+	var review reviewDocument
+	if err := s.reviews.FindOne(ctx, bson.M{"_id": objectID, "reviewerId": user.ID, "deletedAt": bson.M{"$exists": false}}).Decode(&review); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+			return user, reviewDocument{}, false
+		}
+		s.logger.Printf("自分のレビューの取得に失敗 id=%s userId=%s err=%v", objectID.Hex(), user.ID, err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+		return user, reviewDocument{}, false
+	}
+	return user, review, true
+}
+
+func (s *server) myReviewListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+		cursor, err := s.reviews.Find(ctx, bson.M{"reviewerId": user.ID, "deletedAt": bson.M{"$exists": false}}, opts)
+		if err != nil {
+			s.logger.Printf("自分のレビュー一覧の取得に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビュー一覧の取得に失敗しました"})
+			return
+		}
+		var reviews []reviewDocument
+		if err := cursor.All(ctx, &reviews); err != nil {
+			s.logger.Printf("自分のレビュー一覧のデコードに失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビュー一覧の取得に失敗しました"})
+			return
+		}
+
+		storeIDs := make([]primitive.ObjectID, 0, len(reviews))
+		for _, review := range reviews {
+			storeIDs = append(storeIDs, review.StoreID)
+		}
+		storeMap, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.logger.Printf("自分のレビュー一覧用店舗の取得に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		items := make([]myReviewResponse, 0, len(reviews))
+		for _, review := range reviews {
+			items = append(items, s.buildMyReviewResponse(review, storeMap[review.StoreID]))
+		}
+		s.writeJSON(w, http.StatusOK, myReviewListResponse{Items: items})
+	}
+}
+
+func (s *server) myReviewDetailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		_, review, ok := s.findOwnReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		store, err := s.getStoreByID(ctx, review.StoreID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("自分のレビュー用店舗の取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.buildMyReviewResponse(review, store))
+	}
+}
+
+func (s *server) myReviewUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		user, existing, ok := s.findOwnReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(existing.Status) != "pending" {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "審査中のレビューのみ編集できます"})
+			return
+		}
+
+		updated, store, ok := s.applyReviewerContent(ctx, w, r, user, existing, "reviewer_edit", bson.M{})
+		if !ok {
+			return
+		}
+
+		s.recordReviewAudit(ctx, updated.ID, "reviewer_edit", user.ID, auditActorReviewer, bson.M{
+			"previousStoreId": existing.StoreID,
+			"storeId":         updated.StoreID,
+		})
+
+		s.logger.Printf("reviewer review update success id=%s userId=%s", updated.ID.Hex(), user.ID)
+		s.writeJSON(w, http.StatusOK, s.buildMyReviewResponse(updated, store))
+	}
+}
+
+func (s *server) applyReviewerContent(ctx context.Context, w http.ResponseWriter, r *http.Request, user authenticatedUser, existing reviewDocument, reason string, extra bson.M) (reviewDocument, storeDocument, bool) {
+	defer r.Body.Close()
+	var req createReviewRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("リクエストの形式が不正です: %v", err),
+		})
+		return reviewDocument{}, storeDocument{}, false
+	}
+	if err := req.validate(); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return reviewDocument{}, storeDocument{}, false
+	}
+	period, err := formatSurveyPeriod(req.VisitedAt)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return reviewDocument{}, storeDocument{}, false
+	}
+
+	category := canonicalIndustryCode(req.Category)
+	store, storeCreated, err := s.findOrCreateStore(ctx, req.StoreName, req.BranchName, req.Prefecture, category)
+	if err != nil {
+		s.logger.Printf("店舗の取得/作成に失敗: %v", err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の処理に失敗しました"})
+		return reviewDocument{}, storeDocument{}, false
+	}
+	if err := checkStoreAcceptsVisit(store, req.VisitedAt, s.location); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return reviewDocument{}, storeDocument{}, false
+	}
+
+	if err := s.ensureNotDuplicateReview(ctx, user.ID, store.ID, period, existing.ID); err != nil {
+		if errors.Is(err, errDuplicateReview) {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return reviewDocument{}, storeDocument{}, false
+		}
+		s.logger.Printf("重複アンケートの確認に失敗 id=%s err=%v", existing.ID.Hex(), err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
+		return reviewDocument{}, storeDocument{}, false
+	}
+
+	if err := s.ensureReviewRevision(ctx, existing, existing.ReviewerID, auditActorReviewer, "original", 0); err != nil {
+		s.logger.Printf("編集前のリビジョン保存に失敗 id=%s err=%v", existing.ID.Hex(), err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
+		return reviewDocument{}, storeDocument{}, false
+	}
+
+	update := bson.M{
+		"storeId":        store.ID,
+		"industryCode":   category,
+		"period":         period,
+		"age":            req.Age,
+		"specScore":      req.SpecScore,
+		"waitTimeHours":  req.WaitTimeHours,
+		"averageEarning": req.AverageEarning,
+		"rating":         req.Rating,
+		"comment":        strings.TrimSpace(req.Comment),
+		"revision":       currentRevision(existing) + 1,
+		"updatedAt":      time.Now().In(s.location),
+	}
+	candidate := existing
+	candidate.StoreID = store.ID
+	candidate.Period = period
+	candidate.Comment = strings.TrimSpace(req.Comment)
+	candidate.IndustryCode = category
+	candidate.Age = intPtr(req.Age)
+	candidate.SpecScore = intPtr(req.SpecScore)
+	candidate.WaitTimeHours = intPtr(req.WaitTimeHours)
+	candidate.AverageEarning = intPtr(req.AverageEarning)
+	candidate.Rating = req.Rating
+	findings := s.scanReviewContent(ctx, candidate.Comment)
+	update["contentFindings"] = findings
+	if flags, err := s.detectReviewFlags(ctx, candidate); err != nil {
+		s.logger.Printf("重複アンケートの検知に失敗 id=%s err=%v", existing.ID.Hex(), err)
+	} else {
+		update["flags"] = append(flags, contentFlags(findings, time.Now().In(s.location))...)
+	}
+	if anomaly, err := s.scoreReviewAnomaly(ctx, candidate, store, req.clamped); err != nil {
+		s.logger.Printf("外れ値スコアの算出に失敗 id=%s err=%v", existing.ID.Hex(), err)
+	} else {
+		update["anomaly"] = keepAnomalyOverride(existing.Anomaly, anomaly)
+	}
+	for key, value := range extra {
+		update[key] = value
+	}
+	campaign, rematched := s.rematchReviewCampaign(ctx, existing, candidate, store)
+
+	var updated reviewDocument
+	filter := bson.M{"_id": existing.ID, "reviewerId": user.ID, "status": existing.Status, "revision": revisionMatch(existing)}
+	result := s.reviews.FindOneAndUpdate(ctx, filter, withCampaignUpdate(update, campaign, rematched), options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&updated); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "レビューの状態が変更されたため更新できませんでした"})
+			return reviewDocument{}, storeDocument{}, false
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": errDuplicateReview.Error()})
+			return reviewDocument{}, storeDocument{}, false
+		}
+		s.logger.Printf("自分のレビューの更新に失敗 id=%s err=%v", existing.ID.Hex(), err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
+		return reviewDocument{}, storeDocument{}, false
+	}
+
+	if storeCreated {
+		s.emitWebhookEvent(webhookEventStoreCreated, storeDocumentToAdminResponse(store))
+	}
+	if err := s.ensureReviewRevision(ctx, updated, user.ID, auditActorReviewer, reason, 0); err != nil {
+		s.logger.Printf("リビジョンの保存に失敗 id=%s revision=%d err=%v", updated.ID.Hex(), updated.Revision, err)
+	}
+	s.indexReviewComment(ctx, updated)
+
+	if category != "" {
+		if _, err := s.stores.UpdateByID(ctx, store.ID, bson.M{"$addToSet": bson.M{"industryCodes": category}}); err != nil {
+			s.logger.Printf("店舗業種の更新に失敗: %v", err)
+		}
+	}
+	if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+		s.logger.Printf("店舗統計の更新に失敗: %v", err)
+	}
+	if existing.StoreID != updated.StoreID && !existing.StoreID.IsZero() {
+		if err := s.recalculateStoreStats(ctx, existing.StoreID); err != nil {
+			s.logger.Printf("旧店舗統計の更新に失敗 storeId=%s err=%v", existing.StoreID.Hex(), err)
+		}
+	}
+
+	return updated, store, true
+}
+
+func (s *server) myReviewWithdrawHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		user, existing, ok := s.findOwnReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		previousStatus := strings.TrimSpace(existing.Status)
+		if previousStatus == reviewStatusWithdrawn {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューは既に取り下げられています"})
+			return
+		}
+		if strings.TrimSpace(existing.Reward.Status) == rewardStatusSent {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "謝礼送付済みのレビューは取り下げできません"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		filter := bson.M{
+			"_id":           existing.ID,
+			"reviewerId":    user.ID,
+			"status":        existing.Status,
+			"reward.status": bson.M{"$ne": rewardStatusSent},
+		}
+		update := bson.M{"$set": bson.M{
+			"status":      reviewStatusWithdrawn,
+			"withdrawnAt": now,
+			"updatedAt":   now,
+		}}
+		var updated reviewDocument
+		result := s.reviews.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "レビューの状態が変更されたため取り下げできませんでした"})
+				return
+			}
+			s.logger.Printf("自分のレビューの取り下げに失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取り下げに失敗しました"})
+			return
+		}
+
+		if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+			s.logger.Printf("店舗統計の更新に失敗: %v", err)
+		}
+		s.recordReviewAudit(ctx, updated.ID, "reviewer_withdraw", user.ID, auditActorReviewer, bson.M{
+			"previousStatus": previousStatus,
+		})
+		if _, err := s.refreshReviewerProfile(ctx, user.ID); err != nil {
+			s.logger.Printf("レビュアープロフィールの更新に失敗 reviewerId=%s err=%v", user.ID, err)
+		}
+		s.settleReferral(ctx, updated)
+		s.indexReviewComment(ctx, updated)
+		s.releaseRewardLink(ctx, updated, user.ID, "reviewer_withdraw")
+
+		s.logger.Printf("reviewer review withdraw success id=%s userId=%s previousStatus=%q", updated.ID.Hex(), user.ID, previousStatus)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discordNotifierGateway = "gateway"
+	discordNotifierWebhook = "webhook"
+)
+
+type reviewNotification struct {
+	User        authenticatedUser
+	Summary     reviewSummaryResponse
+	Comment     string
+	Resubmitted bool
+}
+
+type notification struct {
+	Recipient      string
+	Subject        string
+	Text           string
+	IdempotencyKey string
+	Review         *reviewNotification
+}
+
+type notifier interface {
+	send(ctx context.Context, message notification) error
+}
+
+type messengerNotifier struct {
+	srv         *server
+	destination string
+}
+
+func (n *messengerNotifier) send(ctx context.Context, message notification) error {
+	return n.srv.sendMessengerMessage(ctx, n.destination, message.Recipient, message.Text, message.IdempotencyKey)
+}
+
+func (s *server) notifyReviewer(ctx context.Context, reviewerID string, message notification) {
+	if strings.TrimSpace(reviewerID) == "" {
+		return
+	}
+	_ = s.sendToReviewer(ctx, reviewerID, message)
+}
+
+// sendToReviewer は LINE とメールの両方へ送り、どちらかに届けば成功として扱う。
+func (s *server) sendToReviewer(ctx context.Context, reviewerID string, message notification) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	reviewerID = strings.TrimSpace(reviewerID)
+	if reviewerID == "" {
+		return errors.New("通知先ユーザーIDが空です")
+	}
+
+	message.Recipient = reviewerID
+	lineErr := s.lineNotifier.send(ctx, message)
+	if lineErr != nil && s.logger != nil {
+		s.logger.Printf("LINE通知の送信に失敗: %v", lineErr)
+	}
+	emailed, emailErr := s.sendReviewerEmail(ctx, reviewerID, message)
+	if emailErr != nil && s.logger != nil {
+		s.logger.Printf("メール通知の送信に失敗 userId=%s err=%v", reviewerID, emailErr)
+	}
+	if lineErr == nil || emailed {
+		return nil
+	}
+	return errors.Join(lineErr, emailErr)
+}
+
+type notificationField struct {
+	Title string
+	Value string
+}
+
+func reviewNotificationFields(summary reviewSummaryResponse, comment string) []notificationField {
+	fields := []notificationField{}
+
+	addField := func(title, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		fields = append(fields, notificationField{Title: title, Value: value})
+	}
+
+	addField("店舗名", summary.StoreName)
+	addField("支店名", summary.BranchName)
+	addField("都道府県", summary.Prefecture)
+	addField("訪問時期", formatVisitedDisplay(summary.VisitedAt))
+	if summary.AverageEarning > 0 {
+		addField("平均稼ぎ", fmt.Sprintf("%d万円", summary.AverageEarning))
+	}
+	if summary.WaitTimeHours > 0 {
+		addField("待機時間", fmt.Sprintf("%d時間", summary.WaitTimeHours))
+	}
+	if summary.Age > 0 {
+		addField("年齢", fmt.Sprintf("%d歳", summary.Age))
+	}
+	if summary.SpecScore > 0 {
+		addField("スペック", fmt.Sprintf("%d", summary.SpecScore))
+	}
+	addField("客層・スタッフ・環境等", comment)
+	if summary.Rating > 0 {
+		addField("満足度", formatRatingValue(summary.Rating))
+	}
+
+	return fields
+}
+
+func adminReviewLink(adminBaseURL, reviewID string) string {
+	trimmed := strings.TrimSpace(adminBaseURL)
+	if trimmed == "" {
+		return ""
+	}
+	link := strings.TrimSuffix(trimmed, "/")
+	if reviewID != "" {
+		link = link + "/" + reviewID
+	}
+	return link
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordEmbedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	URL         string              `json:"url,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Author      *discordEmbedAuthor `json:"author,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordComponent struct {
+	Type       int                `json:"type"`
+	Style      int                `json:"style,omitempty"`
+	Label      string             `json:"label,omitempty"`
+	URL        string             `json:"url,omitempty"`
+	Components []discordComponent `json:"components,omitempty"`
+}
+
+type discordWebhookPayload struct {
+	Content    string             `json:"content,omitempty"`
+	Embeds     []discordEmbed     `json:"embeds,omitempty"`
+	Components []discordComponent `json:"components,omitempty"`
+}
+
+const discordReviewEmbedColor = 0xE91E63
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func buildDiscordReviewPayload(adminBaseURL string, review reviewNotification) discordWebhookPayload {
+	summary := review.Summary
+	link := adminReviewLink(adminBaseURL, summary.ID)
+
+	embed := discordEmbed{
+		Title: "📝 アンケートが投稿されました",
+		URL:   link,
+		Color: discordReviewEmbedColor,
+	}
+	if review.Resubmitted {
+		embed.Title = "🔁 アンケートが再提出されました"
+	}
+	if parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(summary.CreatedAt)); err == nil {
+		embed.Timestamp = parsed.UTC().Format(time.RFC3339)
+	}
+	if username := strings.TrimSpace(review.User.Username); username != "" {
+		embed.Author = &discordEmbedAuthor{
+			Name: "@" + username,
+			URL:  "https://twitter.com/" + url.PathEscape(username),
+		}
+	} else {
+		embed.Author = &discordEmbedAuthor{Name: "投稿者: (未設定)"}
+	}
+
+	for _, field := range reviewNotificationFields(summary, review.Comment) {
+		if field.Title == "客層・スタッフ・環境等" {
+			embed.Description = truncateRunes(field.Value, 4000)
+			continue
+		}
+		embed.Fields = append(embed.Fields, discordEmbedField{
+			Name:   field.Title,
+			Value:  truncateRunes(field.Value, 1000),
+			Inline: true,
+		})
+	}
+
+	payload := discordWebhookPayload{
+		Content: "内容を確認のうえ、PayPay 送付対応を進めてください。",
+		Embeds:  []discordEmbed{embed},
+	}
+	if link != "" {
+		payload.Components = []discordComponent{{
+			Type: 1,
+			Components: []discordComponent{{
+				Type:  2,
+				Style: 5,
+				Label: "管理画面を開く",
+				URL:   link,
+			}},
+		}}
+	}
+	return payload
+}
+
+type discordWebhookNotifier struct {
+	client       *http.Client
+	webhookURL   string
+	adminBaseURL string
+	logger       *log.Logger
+	maxAttempts  int
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func newDiscordWebhookNotifier(client *http.Client, webhookURL, adminBaseURL string, logger *log.Logger) *discordWebhookNotifier {
+	endpoint := strings.TrimSpace(webhookURL)
+	if parsed, err := url.Parse(endpoint); err == nil {
+		query := parsed.Query()
+		query.Set("wait", "true")
+		query.Set("with_components", "true")
+		parsed.RawQuery = query.Encode()
+		endpoint = parsed.String()
+	}
+	return &discordWebhookNotifier{
+		client:       client,
+		webhookURL:   endpoint,
+		adminBaseURL: adminBaseURL,
+		logger:       logger,
+		maxAttempts:  3,
+	}
+}
+
+func (n *discordWebhookNotifier) send(ctx context.Context, message notification) error {
+	var payload discordWebhookPayload
+	if message.Review != nil {
+		payload = buildDiscordReviewPayload(n.adminBaseURL, *message.Review)
+	} else {
+		text := strings.TrimSpace(message.Text)
+		if text == "" {
+			return errors.New("Discord 送信本文が空です")
+		}
+		payload = discordWebhookPayload{Content: truncateRunes(text, 2000)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("Discord 送信用ペイロードの作成に失敗: %w", err)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if err := n.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		retryAfter, err := n.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter <= 0 || attempt == n.maxAttempts {
+			return err
+		}
+		if n.logger != nil {
+			n.logger.Printf("Discord レート制限のため %s 後に再送します attempt=%d", retryAfter, attempt)
+		}
+		n.block(retryAfter)
+	}
+	return nil
+}
+
+func (n *discordWebhookNotifier) post(ctx context.Context, body []byte) (time.Duration, error) {
+	timeout := n.client.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctxWithTimeout, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("Discord 送信リクエストの作成に失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("Discord 送信リクエストに失敗: %w", err)
+	}
+	defer res.Body.Close()
+
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		if resetAfter := parseRateLimitSeconds(res.Header.Get("X-RateLimit-Reset-After")); resetAfter > 0 {
+			n.block(resetAfter)
+		}
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRateLimitSeconds(res.Header.Get("Retry-After"))
+		var rateLimit struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if err := json.Unmarshal(message, &rateLimit); err == nil && rateLimit.RetryAfter > 0 {
+			retryAfter = time.Duration(rateLimit.RetryAfter * float64(time.Second))
+		}
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return retryAfter, fmt.Errorf("Discord のレート制限に達しました: retryAfter=%s", retryAfter)
+	}
+
+	if res.StatusCode >= 400 {
+		return 0, fmt.Errorf("Discord 送信でエラーが発生: status=%d body=%s", res.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return 0, nil
+}
+
+func (n *discordWebhookNotifier) block(wait time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	until := time.Now().Add(wait)
+	if until.After(n.blockedUntil) {
+		n.blockedUntil = until
+	}
+}
+
+func (n *discordWebhookNotifier) waitForRateLimit(ctx context.Context) error {
+	n.mu.Lock()
+	wait := time.Until(n.blockedUntil)
+	n.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func parseRateLimitSeconds(raw string) time.Duration {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+	return time.Duration(value * float64(time.Second))
+}
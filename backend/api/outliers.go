@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	anomalyScopeStore      = "store"
+	anomalyScopePrefecture = "prefecture"
+	anomalyScopeCategory   = "category"
+
+	anomalyMinSamples      = 5
+	anomalySampleLimit     = 500
+	anomalyClampedScore    = 5.0
+	anomalyModifiedZFactor = 0.6745
+)
+
+var anomalyMetricLabels = map[string]string{
+	"averageEarning": "平均稼ぎ",
+	"waitTimeHours":  "待機時間",
+	"rating":         "総評",
+	"specScore":      "スペック",
+	"age":            "年齢",
+}
+
+var anomalyScopeLabels = map[string]string{
+	anomalyScopeStore:      "店舗",
+	anomalyScopePrefecture: "都道府県",
+	anomalyScopeCategory:   "業種",
+}
+
+type anomalyReasonDocument struct {
+	Metric  string   `bson:"metric" json:"metric"`
+	Scope   string   `bson:"scope" json:"scope"`
+	Value   float64  `bson:"value" json:"value"`
+	Median  *float64 `bson:"median,omitempty" json:"median,omitempty"`
+	Samples int      `bson:"samples,omitempty" json:"samples,omitempty"`
+	Score   float64  `bson:"score" json:"score"`
+	Message string   `bson:"message" json:"message"`
+}
+
+type reviewAnomalyDocument struct {
+	Score       float64                 `bson:"score"`
+	Reasons     []anomalyReasonDocument `bson:"reasons,omitempty"`
+	Outlier     bool                    `bson:"outlier"`
+	Excluded    bool                    `bson:"excluded"`
+	Manual      bool                    `bson:"manual,omitempty"`
+	EvaluatedAt time.Time               `bson:"evaluatedAt"`
+}
+
+type reviewAnomalyResponse struct {
+	Score    float64                 `json:"score"`
+	Outlier  bool                    `json:"outlier"`
+	Excluded bool                    `json:"excluded"`
+	Reasons  []anomalyReasonDocument `json:"reasons"`
+}
+
+type anomalySample struct {
+	AverageEarning *int     `bson:"averageEarning"`
+	WaitTimeHours  *int     `bson:"waitTimeHours"`
+	Rating         *float64 `bson:"rating"`
+}
+
+func buildAnomalyResponse(doc *reviewAnomalyDocument) *reviewAnomalyResponse {
+	if doc == nil {
+		return nil
+	}
+	reasons := doc.Reasons
+	if reasons == nil {
+		reasons = []anomalyReasonDocument{}
+	}
+	return &reviewAnomalyResponse{
+		Score:    doc.Score,
+		Outlier:  doc.Outlier,
+		Excluded: doc.Excluded,
+		Reasons:  reasons,
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func modifiedZScore(value float64, samples []float64) (float64, float64, bool) {
+	if len(samples) < anomalyMinSamples {
+		return 0, 0, false
+	}
+	center := median(samples)
+	deviations := make([]float64, len(samples))
+	for i, sample := range samples {
+		deviations[i] = math.Abs(sample - center)
+	}
+	mad := median(deviations)
+	if mad == 0 {
+		mean := 0.0
+		for _, deviation := range deviations {
+			mean += deviation
+		}
+		mean /= float64(len(deviations))
+		if mean == 0 {
+			return 0, center, false
+		}
+		return (value - center) / (1.253314 * mean), center, true
+	}
+	return anomalyModifiedZFactor * (value - center) / mad, center, true
+}
+
+type anomalyScope struct {
+	name   string
+	filter bson.M
+}
+
+func (s *server) loadAnomalySamples(ctx context.Context, filter bson.M, excludeID primitive.ObjectID) ([]anomalySample, error) {
+	publicReviewFilter(filter)
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(anomalySampleLimit).
+		SetProjection(bson.M{"averageEarning": 1, "waitTimeHours": 1, "rating": 1})
+	cursor, err := s.reviews.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var samples []anomalySample
+	if err := cursor.All(ctx, &samples); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func (s *server) scoreReviewAnomaly(ctx context.Context, review reviewDocument, store storeDocument, clamped []string) (*reviewAnomalyDocument, error) {
+	anomaly := &reviewAnomalyDocument{EvaluatedAt: time.Now().In(s.location)}
+
+	for _, field := range clamped {
+		anomaly.Reasons = append(anomaly.Reasons, anomalyReasonDocument{
+			Metric:  field,
+			Scope:   "input",
+			Score:   anomalyClampedScore,
+			Message: anomalyMetricLabels[field] + "が入力上限を超えていたため丸められました",
+		})
+	}
+
+	scopes := []anomalyScope{
+		{anomalyScopeStore, bson.M{"storeId": review.StoreID}},
+	}
+	if prefecture := strings.TrimSpace(store.Prefecture); prefecture != "" {
+		storeIDs, err := s.findStoreIDs(ctx, prefecture, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(storeIDs) > 0 {
+			scopes = append(scopes, anomalyScope{anomalyScopePrefecture, bson.M{"storeId": bson.M{"$in": storeIDs}}})
+		}
+	}
+	if category := canonicalIndustryCode(review.IndustryCode); category != "" {
+		scopes = append(scopes, anomalyScope{anomalyScopeCategory, bson.M{"industryCode": category}})
+	}
+
+	for _, scope := range scopes {
+		samples, err := s.loadAnomalySamples(ctx, scope.filter, review.ID)
+		if err != nil {
+			return nil, err
+		}
+		metrics := []struct {
+			name  string
+			value *float64
+			pick  func(anomalySample) *float64
+		}{
+			{"averageEarning", intToFloatPtr(review.AverageEarning), func(sample anomalySample) *float64 { return intToFloatPtr(sample.AverageEarning) }},
+			{"waitTimeHours", intToFloatPtr(review.WaitTimeHours), func(sample anomalySample) *float64 { return intToFloatPtr(sample.WaitTimeHours) }},
+			{"rating", &review.Rating, func(sample anomalySample) *float64 { return sample.Rating }},
+		}
+		for _, metric := range metrics {
+			if metric.value == nil {
+				continue
+			}
+			values := make([]float64, 0, len(samples))
+			for _, sample := range samples {
+				if value := metric.pick(sample); value != nil {
+					values = append(values, *value)
+				}
+			}
+			z, center, ok := modifiedZScore(*metric.value, values)
+			if !ok {
+				continue
+			}
+			score := math.Round(math.Abs(z)*100) / 100
+			if score > anomaly.Score {
+				anomaly.Score = score
+			}
+			if score < s.outlierThreshold {
+				continue
+			}
+			direction := "高く"
+			if z < 0 {
+				direction = "低く"
+			}
+			anomaly.Reasons = append(anomaly.Reasons, anomalyReasonDocument{
+				Metric:  metric.name,
+				Scope:   scope.name,
+				Value:   *metric.value,
+				Median:  &center,
+				Samples: len(values),
+				Score:   score,
+				Message: anomalyScopeLabels[scope.name] + "の中央値より" + anomalyMetricLabels[metric.name] + "が極端に" + direction + "なっています",
+			})
+		}
+	}
+
+	if len(clamped) > 0 && anomaly.Score < anomalyClampedScore {
+		anomaly.Score = anomalyClampedScore
+	}
+	anomaly.Outlier = anomaly.Score >= s.outlierThreshold
+	anomaly.Excluded = s.outlierExclude && anomaly.Outlier
+	return anomaly, nil
+}
+
+func keepAnomalyOverride(previous, next *reviewAnomalyDocument) *reviewAnomalyDocument {
+	if previous != nil && previous.Manual && next != nil {
+		next.Excluded = previous.Excluded
+		next.Manual = true
+	}
+	return next
+}
+
+func clampedAnomalyFields(anomaly *reviewAnomalyDocument) []string {
+	if anomaly == nil {
+		return nil
+	}
+	var fields []string
+	for _, reason := range anomaly.Reasons {
+		if reason.Scope == "input" {
+			fields = append(fields, reason.Metric)
+		}
+	}
+	return fields
+}
+
+func (s *server) rescoreReviewAnomaly(ctx context.Context, review *reviewDocument) {
+	store, err := s.getStoreByID(ctx, review.StoreID)
+	if err != nil {
+		s.logger.Printf("外れ値スコア用店舗の取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+	anomaly, err := s.scoreReviewAnomaly(ctx, *review, store, clampedAnomalyFields(review.Anomaly))
+	if err == nil {
+		anomaly = keepAnomalyOverride(review.Anomaly, anomaly)
+	}
+	if err != nil {
+		s.logger.Printf("外れ値スコアの算出に失敗 id=%s err=%v", review.ID.Hex(), err)
+		return
+	}
+	if _, err := s.reviews.UpdateByID(ctx, review.ID, bson.M{"$set": bson.M{"anomaly": anomaly}}); err != nil {
+		s.logger.Printf("外れ値スコアの保存に失敗 id=%s err=%v", review.ID.Hex(), err)
+		return
+	}
+	review.Anomaly = anomaly
+}
+
+type updateReviewAnomalyRequest struct {
+	Excluded *bool `json:"excluded"`
+}
+
+func (s *server) adminReviewAnomalyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req updateReviewAnomalyRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil || req.Excluded == nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "excludedを指定してください"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		review, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if review.Anomaly == nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューは外れ値判定されていません"})
+			return
+		}
+
+		if _, err := s.reviews.UpdateByID(ctx, review.ID, bson.M{"$set": bson.M{"anomaly.excluded": *req.Excluded, "anomaly.manual": true}}); err != nil {
+			s.logger.Printf("外れ値除外設定の更新に失敗 id=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "外れ値設定の更新に失敗しました"})
+			return
+		}
+		review.Anomaly.Excluded = *req.Excluded
+		review.Anomaly.Manual = true
+		if err := s.recalculateStoreStats(ctx, review.StoreID); err != nil {
+			s.logger.Printf("外れ値除外後の店舗統計更新に失敗 id=%s err=%v", review.ID.Hex(), err)
+		}
+
+		store, err := s.getStoreByID(ctx, review.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin review anomaly update id=%s excluded=%t", review.ID.Hex(), *req.Excluded)
+		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(review, store))
+	}
+}
+
+func intToFloatPtr(value *int) *float64 {
+	if value == nil {
+		return nil
+	}
+	converted := float64(*value)
+	return &converted
+}
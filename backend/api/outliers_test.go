@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"奇数個", []float64{3, 1, 2}, 2},
+		{"偶数個", []float64{4, 1, 3, 2}, 2.5},
+		{"1件", []float64{7}, 7},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := median(tc.values); got != tc.want {
+				t.Errorf("median(%v) = %v, want %v", tc.values, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestModifiedZScore(t *testing.T) {
+	cases := []struct {
+		name       string
+		value      float64
+		samples    []float64
+		wantScore  float64
+		wantCenter float64
+		wantOK     bool
+	}{
+		{"サンプル不足", 20, []float64{10, 12, 11, 13}, 0, 0, false},
+		{"MAD による外れ値", 20, []float64{10, 12, 11, 13, 12}, 5.396, 12, true},
+		{"中央値付近", 12, []float64{10, 12, 11, 13, 12}, 0, 12, true},
+		{"MAD が 0 なら平均絶対偏差", 9, []float64{5, 5, 5, 5, 9}, 3.989, 5, true},
+		{"ばらつきなし", 9, []float64{5, 5, 5, 5, 5}, 0, 5, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			score, center, ok := modifiedZScore(tc.value, tc.samples)
+			if ok != tc.wantOK || center != tc.wantCenter || math.Abs(score-tc.wantScore) > 0.001 {
+				t.Errorf("modifiedZScore() = (%.3f, %v, %t), want (%.3f, %v, %t)", score, center, ok, tc.wantScore, tc.wantCenter, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestKeepAnomalyOverride(t *testing.T) {
+	cases := []struct {
+		name     string
+		previous *reviewAnomalyDocument
+		next     *reviewAnomalyDocument
+		want     *reviewAnomalyDocument
+	}{
+		{"前回なし", nil, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: true}, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: true}},
+		{"自動判定は上書き", &reviewAnomalyDocument{Excluded: false}, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: true}, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: true}},
+		{"手動判定を維持", &reviewAnomalyDocument{Excluded: false, Manual: true}, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: true}, &reviewAnomalyDocument{Score: 4, Outlier: true, Excluded: false, Manual: true}},
+		{"再計算結果なし", &reviewAnomalyDocument{Manual: true}, nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := keepAnomalyOverride(tc.previous, tc.next); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("keepAnomalyOverride() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClampedAnomalyFields(t *testing.T) {
+	anomaly := &reviewAnomalyDocument{Reasons: []anomalyReasonDocument{
+		{Metric: "age", Scope: "input"},
+		{Metric: "averageEarning", Scope: anomalyScopeStore},
+		{Metric: "waitTimeHours", Scope: "input"},
+	}}
+	if got, want := clampedAnomalyFields(anomaly), []string{"age", "waitTimeHours"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("clampedAnomalyFields() = %v, want %v", got, want)
+	}
+	if got := clampedAnomalyFields(nil); got != nil {
+		t.Errorf("clampedAnomalyFields(nil) = %v, want nil", got)
+	}
+}
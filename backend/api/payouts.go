@@ -0,0 +1,837 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	rewardLinkAvailable = "available"
+	rewardLinkAssigned  = "assigned"
+	rewardLinkDelivered = "delivered"
+	rewardLinkVoided    = "voided"
+
+	ledgerEventImported  = "imported"
+	ledgerEventIssued    = "issued"
+	ledgerEventDelivered = "delivered"
+	ledgerEventFailed    = "failed"
+	ledgerEventVoided    = "voided"
+	ledgerEventReleased  = "released"
+
+	rewardStatusPending        = "pending"
+	rewardStatusSent           = "sent"
+	rewardStatusDeliveryFailed = "delivery_failed"
+
+	maxRewardLinkImport = 1000
+)
+
+var (
+	errRewardDisabled     = errors.New("報酬リンクの暗号鍵が設定されていません")
+	errRewardOutOfStock   = errors.New("報酬リンクの在庫がありません")
+	errRewardNotEligible  = errors.New("このレビューは報酬の対象外です")
+	errRewardAlreadyTaken = errors.New("このレビューには既に報酬リンクが割り当てられています")
+	errRewardLinkClaimed  = errors.New("この報酬リンクは既に送信済みか送信処理中です")
+)
+
+var rewardPayableStatuses = bson.A{nil, "", rewardStatusPending, rewardStatusDeliveryFailed}
+
+type rewardLinkDocument struct {
+	ID          primitive.ObjectID  `bson:"_id"`
+	Ciphertext  string              `bson:"ciphertext"`
+	Fingerprint string              `bson:"fingerprint"`
+	Hint        string              `bson:"hint"`
+	Amount      int                 `bson:"amount"`
+	Status      string              `bson:"status"`
+	BatchID     string              `bson:"batchId"`
+	ReviewID    *primitive.ObjectID `bson:"reviewId,omitempty"`
+	ReviewerID  string              `bson:"reviewerId,omitempty"`
+	ImportedBy  string              `bson:"importedBy,omitempty"`
+	ImportedAt  time.Time           `bson:"importedAt"`
+	ExpiresAt   *time.Time          `bson:"expiresAt,omitempty"`
+	AssignedAt  *time.Time          `bson:"assignedAt,omitempty"`
+	DeliveredAt *time.Time          `bson:"deliveredAt,omitempty"`
+	VoidedAt    *time.Time          `bson:"voidedAt,omitempty"`
+	VoidReason  string              `bson:"voidReason,omitempty"`
+}
+
+type rewardLedgerDocument struct {
+	ID         primitive.ObjectID  `bson:"_id"`
+	Event      string              `bson:"event"`
+	LinkID     *primitive.ObjectID `bson:"linkId,omitempty"`
+	ReviewID   *primitive.ObjectID `bson:"reviewId,omitempty"`
+	ReviewerID string              `bson:"reviewerId,omitempty"`
+	Amount     int                 `bson:"amount,omitempty"`
+	Actor      string              `bson:"actor,omitempty"`
+	Requester  string              `bson:"requestedBy,omitempty"`
+	Confirmer  string              `bson:"confirmedBy,omitempty"`
+	Detail     string              `bson:"detail,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt"`
+}
+
+type rewardLinkResponse struct {
+	ID          string     `json:"id"`
+	Hint        string     `json:"hint"`
+	Amount      int        `json:"amount"`
+	Status      string     `json:"status"`
+	BatchID     string     `json:"batchId"`
+	ReviewID    string     `json:"reviewId,omitempty"`
+	ReviewerID  string     `json:"reviewerId,omitempty"`
+	ImportedBy  string     `json:"importedBy,omitempty"`
+	ImportedAt  time.Time  `json:"importedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	AssignedAt  *time.Time `json:"assignedAt,omitempty"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	VoidedAt    *time.Time `json:"voidedAt,omitempty"`
+	VoidReason  string     `json:"voidReason,omitempty"`
+}
+
+type rewardLedgerResponse struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	LinkID     string    `json:"linkId,omitempty"`
+	ReviewID   string    `json:"reviewId,omitempty"`
+	ReviewerID string    `json:"reviewerId,omitempty"`
+	Amount     int       `json:"amount,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Requester  string    `json:"requestedBy,omitempty"`
+	Confirmer  string    `json:"confirmedBy,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type importRewardLinksRequest struct {
+	Links      []string `json:"links"`
+	Text       string   `json:"text"`
+	Amount     int      `json:"amount"`
+	ExpiresAt  string   `json:"expiresAt"`
+	ImportedBy string   `json:"importedBy"`
+}
+
+type rewardActionRequest struct {
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+func parseRewardLinkKey(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("REWARD_LINK_ENCRYPTION_KEY は32バイトのbase64またはhexで指定してください")
+}
+
+func newRewardCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *server) encryptRewardLink(plain string) (string, error) {
+	if s.rewardCipher == nil {
+		return "", errRewardDisabled
+	}
+	nonce := make([]byte, s.rewardCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.rewardCipher.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *server) decryptRewardLink(encoded string) (string, error) {
+	if s.rewardCipher == nil {
+		return "", errRewardDisabled
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	size := s.rewardCipher.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("暗号文が不正です")
+	}
+	plain, err := s.rewardCipher.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func rewardLinkFingerprint(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:])
+}
+
+func rewardLinkHint(link string) string {
+	runes := []rune(link)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return "****" + string(runes[len(runes)-4:])
+}
+
+func rewardLinkToResponse(doc rewardLinkDocument) rewardLinkResponse {
+	response := rewardLinkResponse{
+		ID:          doc.ID.Hex(),
+		Hint:        doc.Hint,
+		Amount:      doc.Amount,
+		Status:      doc.Status,
+		BatchID:     doc.BatchID,
+		ReviewerID:  doc.ReviewerID,
+		ImportedBy:  doc.ImportedBy,
+		ImportedAt:  doc.ImportedAt,
+		ExpiresAt:   doc.ExpiresAt,
+		AssignedAt:  doc.AssignedAt,
+		DeliveredAt: doc.DeliveredAt,
+		VoidedAt:    doc.VoidedAt,
+		VoidReason:  doc.VoidReason,
+	}
+	if doc.ReviewID != nil {
+		response.ReviewID = doc.ReviewID.Hex()
+	}
+	return response
+}
+
+func rewardLedgerToResponse(doc rewardLedgerDocument) rewardLedgerResponse {
+	response := rewardLedgerResponse{
+		ID:         doc.ID.Hex(),
+		Event:      doc.Event,
+		ReviewerID: doc.ReviewerID,
+		Amount:     doc.Amount,
+		Actor:      doc.Actor,
+		Requester:  doc.Requester,
+		Confirmer:  doc.Confirmer,
+		Detail:     doc.Detail,
+		CreatedAt:  doc.CreatedAt,
+	}
+	if doc.LinkID != nil {
+		response.LinkID = doc.LinkID.Hex()
+	}
+	if doc.ReviewID != nil {
+		response.ReviewID = doc.ReviewID.Hex()
+	}
+	return response
+}
+
+func (s *server) ensureRewardLinkIndex(ctx context.Context) error {
+	_, err := s.rewardLinks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "fingerprint", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "importedAt", Value: 1}}},
+	})
+	return err
+}
+
+func (s *server) recordRewardLedger(ctx context.Context, entry rewardLedgerDocument) {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now().In(s.location)
+	if _, err := s.rewardLedger.InsertOne(ctx, entry); err != nil {
+		s.logger.Printf("報酬台帳の記録に失敗 event=%s err=%v", entry.Event, err)
+	}
+}
+
+func rewardEligible(review reviewDocument) bool {
+	if strings.TrimSpace(review.ReviewerID) == "" || !isReviewPublic(review) {
+		return false
+	}
+	switch strings.TrimSpace(review.Reward.Status) {
+	case "", rewardStatusPending, rewardStatusDeliveryFailed:
+		return true
+	}
+	return false
+}
+
+func (s *server) assignRewardLink(ctx context.Context, review reviewDocument, actor string) (rewardLinkDocument, error) {
+	if s.rewardCipher == nil {
+		return rewardLinkDocument{}, errRewardDisabled
+	}
+	if !rewardEligible(review) {
+		return rewardLinkDocument{}, errRewardNotEligible
+	}
+	if review.Reward.LinkID != nil {
+		return rewardLinkDocument{}, errRewardAlreadyTaken
+	}
+
+	now := time.Now().In(s.location)
+	filter := bson.M{
+		"status": rewardLinkAvailable,
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+			bson.M{"expiresAt": bson.M{"$gt": now}},
+		},
+	}
+	if review.Reward.Amount > 0 {
+		filter["amount"] = review.Reward.Amount
+	}
+	var link rewardLinkDocument
+	err := s.rewardLinks.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{
+		"status":     rewardLinkAssigned,
+		"reviewId":   review.ID,
+		"reviewerId": review.ReviewerID,
+		"assignedAt": now,
+	}}, options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "importedAt", Value: 1}}).
+		SetReturnDocument(options.After)).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rewardLinkDocument{}, errRewardOutOfStock
+	}
+	if err != nil {
+		return rewardLinkDocument{}, err
+	}
+
+	result, err := s.reviews.UpdateOne(ctx, bson.M{
+		"_id":           review.ID,
+		"reward.linkId": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{
+		"reward.linkId": link.ID,
+		"reward.amount": link.Amount,
+		"updatedAt":     now,
+	}})
+	if err != nil || result.MatchedCount == 0 {
+		if _, releaseErr := s.rewardLinks.UpdateByID(ctx, link.ID, bson.M{
+			"$set":   bson.M{"status": rewardLinkAvailable},
+			"$unset": bson.M{"reviewId": "", "reviewerId": "", "assignedAt": ""},
+		}); releaseErr != nil {
+			s.logger.Printf("報酬リンクの割当解除に失敗 linkId=%s err=%v", link.ID.Hex(), releaseErr)
+		}
+		if err != nil {
+			return rewardLinkDocument{}, err
+		}
+		return rewardLinkDocument{}, errRewardAlreadyTaken
+	}
+
+	s.recordRewardLedger(ctx, rewardLedgerDocument{
+		Event:      ledgerEventIssued,
+		LinkID:     &link.ID,
+		ReviewID:   &review.ID,
+		ReviewerID: review.ReviewerID,
+		Requester:  review.Reward.RequestedBy,
+		Confirmer:  review.Reward.ConfirmedBy,
+		Amount:     link.Amount,
+		Actor:      actor,
+	})
+	return link, nil
+}
+
+func (s *server) claimRewardLink(ctx context.Context, linkID, reviewID primitive.ObjectID, deliveredAt time.Time) (bool, error) {
+	result, err := s.rewardLinks.UpdateOne(ctx, bson.M{
+		"_id":      linkID,
+		"status":   rewardLinkAssigned,
+		"reviewId": reviewID,
+	}, bson.M{"$set": bson.M{
+		"status":      rewardLinkDelivered,
+		"deliveredAt": deliveredAt,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func buildRewardDeliveryMessage(amount int, link string) string {
+	lines := []string{
+		"アンケートのご協力ありがとうございました！",
+		fmt.Sprintf("PayPay%d円分のリンクをお送りします。", amount),
+		"",
+		link,
+		"",
+		"受け取り期限がある場合がありますので、お早めにお受け取りください。",
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *server) deliverRewardLink(ctx context.Context, review reviewDocument, link rewardLinkDocument, actor string) error {
+	now := time.Now().In(s.location)
+	claimed, err := s.claimRewardLink(ctx, link.ID, review.ID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errRewardLinkClaimed
+	}
+
+	plain, err := s.decryptRewardLink(link.Ciphertext)
+	if err == nil {
+		err = s.sendToReviewer(ctx, review.ReviewerID, notification{
+			Subject:        "アンケート謝礼のお届け",
+			Text:           buildRewardDeliveryMessage(link.Amount, plain),
+			IdempotencyKey: fmt.Sprintf("reward-link:%s:%s", review.ID.Hex(), link.ID.Hex()),
+		})
+	}
+
+	if err != nil {
+		if _, revertErr := s.rewardLinks.UpdateOne(ctx, bson.M{"_id": link.ID, "status": rewardLinkDelivered}, bson.M{
+			"$set":   bson.M{"status": rewardLinkAssigned},
+			"$unset": bson.M{"deliveredAt": ""},
+		}); revertErr != nil {
+			s.logger.Printf("報酬リンクの送信取消に失敗 linkId=%s err=%v", link.ID.Hex(), revertErr)
+		}
+		s.recordRewardLedger(ctx, rewardLedgerDocument{
+			Event:      ledgerEventFailed,
+			LinkID:     &link.ID,
+			ReviewID:   &review.ID,
+			ReviewerID: review.ReviewerID,
+			Requester:  review.Reward.RequestedBy,
+			Confirmer:  review.Reward.ConfirmedBy,
+			Amount:     link.Amount,
+			Actor:      actor,
+			Detail:     err.Error(),
+		})
+		if _, updateErr := s.reviews.UpdateByID(ctx, review.ID, bson.M{"$set": bson.M{
+			"reward.status": rewardStatusDeliveryFailed,
+			"reward.note":   "報酬リンクの送信に失敗しました",
+			"updatedAt":     now,
+		}}); updateErr != nil {
+			s.logger.Printf("報酬送信失敗の記録に失敗 id=%s err=%v", review.ID.Hex(), updateErr)
+		}
+		return err
+	}
+
+	var updated reviewDocument
+	if err := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": review.ID}, bson.M{"$set": bson.M{
+		"reward.status": rewardStatusSent,
+		"reward.sentAt": now,
+		"reward.note":   "報酬リンクを自動送信しました",
+		"updatedAt":     now,
+	}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated); err != nil {
+		s.logger.Printf("報酬送信済みの記録に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+	s.recordRewardLedger(ctx, rewardLedgerDocument{
+		Event:      ledgerEventDelivered,
+		LinkID:     &link.ID,
+		ReviewID:   &review.ID,
+		ReviewerID: review.ReviewerID,
+		Requester:  review.Reward.RequestedBy,
+		Confirmer:  review.Reward.ConfirmedBy,
+		Amount:     link.Amount,
+		Actor:      actor,
+	})
+	if !updated.ID.IsZero() {
+		if store, err := s.getStoreByID(ctx, updated.StoreID); err == nil {
+			s.emitWebhookEvent(webhookEventRewardSent, buildAdminReviewResponse(updated, store))
+		}
+	}
+	return nil
+}
+
+func (s *server) issueReviewReward(ctx context.Context, review reviewDocument, actor string) error {
+	var (
+		link rewardLinkDocument
+		err  error
+	)
+	if !rewardEligible(review) {
+		return errRewardNotEligible
+	}
+	if review, err = s.commitRewardIssue(ctx, review, actor); err != nil {
+		return err
+	}
+	if !s.rewardConfirmed(review) {
+		if strings.TrimSpace(review.Reward.Status) != rewardStatusAwaiting {
+			s.requestRewardConfirmation(ctx, review, actor)
+		}
+		return errRewardUnconfirmed
+	}
+	if !rewardEligible(review) {
+		return errRewardNotEligible
+	}
+	if review.Reward.LinkID != nil {
+		err = s.rewardLinks.FindOne(ctx, bson.M{"_id": *review.Reward.LinkID}).Decode(&link)
+	} else {
+		link, err = s.assignRewardLink(ctx, review, actor)
+	}
+	if err != nil {
+		if errors.Is(err, errRewardOutOfStock) {
+			s.recordRewardLedger(ctx, rewardLedgerDocument{
+				Event:      ledgerEventFailed,
+				ReviewID:   &review.ID,
+				ReviewerID: review.ReviewerID,
+				Requester:  review.Reward.RequestedBy,
+				Confirmer:  review.Reward.ConfirmedBy,
+				Actor:      actor,
+				Detail:     err.Error(),
+			})
+		}
+		return err
+	}
+	return s.deliverRewardLink(ctx, review, link, actor)
+}
+
+func (s *server) releaseRewardLink(ctx context.Context, review reviewDocument, actor, detail string) {
+	if review.Reward.LinkID == nil || strings.TrimSpace(review.Reward.Status) == rewardStatusSent {
+		return
+	}
+	linkID := *review.Reward.LinkID
+	now := time.Now().In(s.location)
+	event := ledgerEventReleased
+	update := bson.M{
+		"$set":   bson.M{"status": rewardLinkAvailable},
+		"$unset": bson.M{"reviewId": "", "reviewerId": "", "assignedAt": ""},
+	}
+	if strings.TrimSpace(review.Reward.Status) == rewardStatusDeliveryFailed {
+		event = ledgerEventVoided
+		update = bson.M{"$set": bson.M{
+			"status":     rewardLinkVoided,
+			"voidedAt":   now,
+			"voidReason": "送信失敗後にレビューが非公開になったため無効化しました",
+		}}
+	}
+	result, err := s.rewardLinks.UpdateOne(ctx, bson.M{"_id": linkID, "status": rewardLinkAssigned, "reviewId": review.ID}, update)
+	if err != nil {
+		s.logger.Printf("報酬リンクの割当解除に失敗 linkId=%s err=%v", linkID.Hex(), err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		return
+	}
+	if _, err := s.reviews.UpdateOne(ctx, bson.M{"_id": review.ID, "reward.linkId": linkID}, bson.M{
+		"$set":   bson.M{"updatedAt": now},
+		"$unset": bson.M{"reward.linkId": ""},
+	}); err != nil {
+		s.logger.Printf("レビューの報酬リンク解除に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+	s.recordRewardLedger(ctx, rewardLedgerDocument{
+		Event:      event,
+		LinkID:     &linkID,
+		ReviewID:   &review.ID,
+		ReviewerID: review.ReviewerID,
+		Amount:     review.Reward.Amount,
+		Actor:      actor,
+		Detail:     detail,
+	})
+}
+
+func (s *server) autoIssueReviewReward(review reviewDocument) {
+	if s.rewardCipher == nil || !s.rewardAutoDelivery {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := s.issueReviewReward(ctx, review, "auto"); err != nil && !errors.Is(err, errRewardNotEligible) && !errors.Is(err, errRewardAlreadyTaken) && !errors.Is(err, errRewardLinkClaimed) && !errors.Is(err, errRewardHeld) && !errors.Is(err, errRewardUnconfirmed) {
+		s.logger.Printf("報酬リンクの自動送信に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+}
+
+func (s *server) adminRewardLinkImportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.rewardCipher == nil {
+			s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": errRewardDisabled.Error()})
+			return
+		}
+		var req importRewardLinksRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		links := make([]string, 0, len(req.Links))
+		seen := make(map[string]struct{})
+		for _, raw := range append(req.Links, strings.Split(req.Text, "\n")...) {
+			link := strings.TrimSpace(raw)
+			if link == "" {
+				continue
+			}
+			if _, ok := seen[link]; ok {
+				continue
+			}
+			seen[link] = struct{}{}
+			links = append(links, link)
+		}
+		if len(links) == 0 {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "登録するリンクを指定してください"})
+			return
+		}
+		if len(links) > maxRewardLinkImport {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("一度に登録できるリンクは%d件までです", maxRewardLinkImport)})
+			return
+		}
+		amount := req.Amount
+		if amount <= 0 {
+			amount = s.rewardDefaultAmount
+		}
+		var expiresAt *time.Time
+		if raw := strings.TrimSpace(req.ExpiresAt); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAtはRFC3339形式で指定してください"})
+				return
+			}
+			expiresAt = &parsed
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		batchID := primitive.NewObjectID().Hex()
+		importedBy := strings.TrimSpace(req.ImportedBy)
+		imported, duplicates := 0, 0
+		for _, link := range links {
+			ciphertext, err := s.encryptRewardLink(link)
+			if err != nil {
+				s.logger.Printf("報酬リンクの暗号化に失敗 err=%v", err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンクの暗号化に失敗しました"})
+				return
+			}
+			doc := rewardLinkDocument{
+				ID:          primitive.NewObjectID(),
+				Ciphertext:  ciphertext,
+				Fingerprint: rewardLinkFingerprint(link),
+				Hint:        rewardLinkHint(link),
+				Amount:      amount,
+				Status:      rewardLinkAvailable,
+				BatchID:     batchID,
+				ImportedBy:  importedBy,
+				ImportedAt:  now,
+				ExpiresAt:   expiresAt,
+			}
+			if _, err := s.rewardLinks.InsertOne(ctx, doc); err != nil {
+				if mongo.IsDuplicateKeyError(err) {
+					duplicates++
+					continue
+				}
+				s.logger.Printf("報酬リンクの保存に失敗 batchId=%s err=%v", batchID, err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンクの保存に失敗しました"})
+				return
+			}
+			imported++
+		}
+
+		s.recordRewardLedger(ctx, rewardLedgerDocument{
+			Event:  ledgerEventImported,
+			Amount: amount * imported,
+			Actor:  importedBy,
+			Detail: fmt.Sprintf("batch=%s imported=%d duplicates=%d", batchID, imported, duplicates),
+		})
+		s.logger.Printf("admin reward link import batchId=%s imported=%d duplicates=%d", batchID, imported, duplicates)
+		s.writeJSON(w, http.StatusCreated, map[string]any{
+			"batchId":    batchID,
+			"imported":   imported,
+			"duplicates": duplicates,
+		})
+	}
+}
+
+func (s *server) adminRewardLinkListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := bson.M{}
+		if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" && status != "all" {
+			filter["status"] = status
+		}
+		if batchID := strings.TrimSpace(r.URL.Query().Get("batchId")); batchID != "" {
+			filter["batchId"] = batchID
+		}
+		limit, _ := parsePositiveInt(r.URL.Query().Get("limit"), 100)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.rewardLinks.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "importedAt", Value: -1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"ciphertext": 0}))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンク一覧の取得に失敗しました"})
+			return
+		}
+		var docs []rewardLinkDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンク一覧の取得に失敗しました"})
+			return
+		}
+		items := make([]rewardLinkResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, rewardLinkToResponse(doc))
+		}
+
+		counts := map[string]int64{}
+		for _, status := range []string{rewardLinkAvailable, rewardLinkAssigned, rewardLinkDelivered, rewardLinkVoided} {
+			count, err := s.rewardLinks.CountDocuments(ctx, bson.M{"status": status})
+			if err != nil {
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンク在庫の集計に失敗しました"})
+				return
+			}
+			counts[status] = count
+		}
+
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items, "counts": counts})
+	}
+}
+
+func (s *server) adminRewardLinkVoidHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リンクIDの形式が不正です"})
+			return
+		}
+		var req rewardActionRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		reason := strings.TrimSpace(req.Reason)
+		actor := adminActor(r, req.Actor)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		var link rewardLinkDocument
+		err = s.rewardLinks.FindOneAndUpdate(ctx, bson.M{
+			"_id":    objectID,
+			"status": bson.M{"$ne": rewardLinkVoided},
+		}, bson.M{"$set": bson.M{
+			"status":     rewardLinkVoided,
+			"voidedAt":   now,
+			"voidReason": reason,
+		}}, options.FindOneAndUpdate().SetProjection(bson.M{"ciphertext": 0})).Decode(&link)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "無効化できる報酬リンクが見つかりません"})
+				return
+			}
+			s.logger.Printf("報酬リンクの無効化に失敗 id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬リンクの無効化に失敗しました"})
+			return
+		}
+
+		if link.Status == rewardLinkAssigned && link.ReviewID != nil {
+			if _, err := s.reviews.UpdateOne(ctx, bson.M{"_id": *link.ReviewID, "reward.linkId": link.ID}, bson.M{
+				"$set":   bson.M{"reward.status": rewardStatusPending, "updatedAt": now},
+				"$unset": bson.M{"reward.linkId": "", "reward.amount": ""},
+			}); err != nil {
+				s.logger.Printf("無効化したリンクの割当解除に失敗 linkId=%s err=%v", link.ID.Hex(), err)
+			}
+		}
+
+		s.recordRewardLedger(ctx, rewardLedgerDocument{
+			Event:      ledgerEventVoided,
+			LinkID:     &link.ID,
+			ReviewID:   link.ReviewID,
+			ReviewerID: link.ReviewerID,
+			Amount:     link.Amount,
+			Actor:      actor,
+			Detail:     reason,
+		})
+		link.VoidedAt = &now
+		link.VoidReason = reason
+		previous := link.Status
+		link.Status = rewardLinkVoided
+		s.logger.Printf("admin reward link void id=%s previous=%s", link.ID.Hex(), previous)
+		s.writeJSON(w, http.StatusOK, rewardLinkToResponse(link))
+	}
+}
+
+func (s *server) adminRewardLedgerHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := bson.M{}
+		for _, key := range []string{"reviewId", "linkId"} {
+			raw := strings.TrimSpace(query.Get(key))
+			if raw == "" {
+				continue
+			}
+			objectID, err := primitive.ObjectIDFromHex(raw)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": key + "の形式が不正です"})
+				return
+			}
+			filter[key] = objectID
+		}
+		if reviewerID := strings.TrimSpace(query.Get("reviewerId")); reviewerID != "" {
+			filter["reviewerId"] = reviewerID
+		}
+		if event := strings.TrimSpace(query.Get("event")); event != "" {
+			filter["event"] = event
+		}
+		limit, _ := parsePositiveInt(query.Get("limit"), 100)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.rewardLedger.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit)))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬台帳の取得に失敗しました"})
+			return
+		}
+		var docs []rewardLedgerDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "報酬台帳の取得に失敗しました"})
+			return
+		}
+		items := make([]rewardLedgerResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, rewardLedgerToResponse(doc))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminReviewRewardDeliverHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req rewardActionRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+
+		review, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if err := s.issueReviewReward(ctx, review, adminActor(r, req.Actor)); err != nil {
+			switch {
+			case errors.Is(err, errRewardDisabled), errors.Is(err, errRewardOutOfStock):
+				s.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+			case errors.Is(err, errRewardNotEligible), errors.Is(err, errRewardAlreadyTaken), errors.Is(err, errRewardLinkClaimed), errors.Is(err, errRewardHeld), errors.Is(err, errRewardConflict), errors.Is(err, errRewardUnconfirmed):
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			default:
+				s.logger.Printf("報酬リンクの送信に失敗 id=%s err=%v", review.ID.Hex(), err)
+				s.writeJSON(w, http.StatusBadGateway, map[string]string{"error": "報酬リンクの送信に失敗しました"})
+			}
+			return
+		}
+
+		updated, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		store, err := s.getStoreByID(ctx, updated.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
+	}
+}
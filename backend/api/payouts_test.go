@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRewardEligible(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		review reviewDocument
+		want   bool
+	}{
+		{"未送付", reviewDocument{Status: "approved", ReviewerID: "u1"}, true},
+		{"pending", reviewDocument{Status: "approved", ReviewerID: "u1", Reward: reviewRewardDocument{Status: rewardStatusPending}}, true},
+		{"送信失敗は再送可", reviewDocument{Status: "approved", ReviewerID: "u1", Reward: reviewRewardDocument{Status: rewardStatusDeliveryFailed}}, true},
+		{"送付済み", reviewDocument{Status: "approved", ReviewerID: "u1", Reward: reviewRewardDocument{Status: rewardStatusSent}}, false},
+		{"保留中", reviewDocument{Status: "approved", ReviewerID: "u1", Reward: reviewRewardDocument{Status: rewardStatusHeld}}, false},
+		{"確認待ち", reviewDocument{Status: "approved", ReviewerID: "u1", Reward: reviewRewardDocument{Status: rewardStatusAwaiting}}, false},
+		{"投稿者なし", reviewDocument{Status: "approved", ReviewerID: " "}, false},
+		{"未承認", reviewDocument{Status: "pending", ReviewerID: "u1"}, false},
+		{"非公開", reviewDocument{Status: reviewStatusUnpublished, ReviewerID: "u1"}, false},
+		{"削除済み", reviewDocument{Status: "approved", ReviewerID: "u1", DeletedAt: &deletedAt}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rewardEligible(tc.review); got != tc.want {
+				t.Errorf("rewardEligible() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRewardLinkKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5a}, 32)
+	cases := []struct {
+		name    string
+		raw     string
+		want    []byte
+		wantErr bool
+	}{
+		{"未設定", "", nil, false},
+		{"base64", base64.StdEncoding.EncodeToString(key), key, false},
+		{"hex", " " + hex.EncodeToString(key) + "\n", key, false},
+		{"短すぎる", base64.StdEncoding.EncodeToString(key[:16]), nil, true},
+		{"形式不正", "not-a-key", nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseRewardLinkKey(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseRewardLinkKey() err = %v, wantErr %t", err, tc.wantErr)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("parseRewardLinkKey() = %x, want %x", got, tc.want)
+			}
+		})
+	}
+}
+
+func newTestRewardServer(t *testing.T, key []byte) *server {
+	t.Helper()
+	aead, err := newRewardCipher(key)
+	if err != nil {
+		t.Fatalf("newRewardCipher() err = %v", err)
+	}
+	return &server{rewardCipher: aead}
+}
+
+func TestRewardLinkCipherRoundTrip(t *testing.T) {
+	s := newTestRewardServer(t, bytes.Repeat([]byte{0x01}, 32))
+	other := newTestRewardServer(t, bytes.Repeat([]byte{0x02}, 32))
+
+	links := []string{
+		"https://pay.paypay.ne.jp/abcdEFGH1234",
+		"https://example.test/報酬?code=あいう",
+		"x",
+	}
+	for _, link := range links {
+		t.Run(link, func(t *testing.T) {
+			first, err := s.encryptRewardLink(link)
+			if err != nil {
+				t.Fatalf("encryptRewardLink() err = %v", err)
+			}
+			second, err := s.encryptRewardLink(link)
+			if err != nil {
+				t.Fatalf("encryptRewardLink() err = %v", err)
+			}
+			if first == second {
+				t.Error("同じリンクの暗号文が一致しました")
+			}
+			plain, err := s.decryptRewardLink(first)
+			if err != nil {
+				t.Fatalf("decryptRewardLink() err = %v", err)
+			}
+			if plain != link {
+				t.Errorf("decryptRewardLink() = %q, want %q", plain, link)
+			}
+			if _, err := other.decryptRewardLink(first); err == nil {
+				t.Error("別の鍵で復号できました")
+			}
+
+			sealed, _ := base64.StdEncoding.DecodeString(first)
+			sealed[len(sealed)-1] ^= 0xff
+			if _, err := s.decryptRewardLink(base64.StdEncoding.EncodeToString(sealed)); err == nil {
+				t.Error("改ざんされた暗号文を復号できました")
+			}
+		})
+	}
+
+	if _, err := s.decryptRewardLink(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("短すぎる暗号文を復号できました")
+	}
+	if _, err := s.decryptRewardLink("%%%"); err == nil {
+		t.Error("base64でない暗号文を復号できました")
+	}
+
+	disabled := newTestRewardServer(t, nil)
+	if _, err := disabled.encryptRewardLink("x"); !errors.Is(err, errRewardDisabled) {
+		t.Errorf("encryptRewardLink() err = %v, want errRewardDisabled", err)
+	}
+	if _, err := disabled.decryptRewardLink("x"); !errors.Is(err, errRewardDisabled) {
+		t.Errorf("decryptRewardLink() err = %v, want errRewardDisabled", err)
+	}
+}
@@ -0,0 +1,725 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	rewardStatusHeld = "held"
+
+	rewardHoldGlobalBudget = "monthly_budget"
+	rewardHoldPolicyBudget = "policy_budget"
+	rewardHoldReviewerCap  = "reviewer_cap"
+	rewardHoldStoreCap     = "store_cap"
+
+	defaultReviewerPeriodDays = 30
+)
+
+var (
+	errRewardHeld     = errors.New("謝礼ポリシーの上限により保留されました")
+	errRewardConflict = errors.New("処理中に謝礼の状態が変更されました")
+)
+
+var rewardCommittedStatuses = []string{rewardStatusPending, rewardStatusAwaiting, rewardStatusSent, rewardStatusDeliveryFailed}
+
+type rewardPolicyDocument struct {
+	ID                 primitive.ObjectID `bson:"_id"`
+	Name               string             `bson:"name"`
+	Campaign           string             `bson:"campaign,omitempty"`
+	Amount             int                `bson:"amount"`
+	MonthlyBudget      int                `bson:"monthlyBudget,omitempty"`
+	ReviewerCap        int                `bson:"reviewerCap,omitempty"`
+	ReviewerPeriodDays int                `bson:"reviewerPeriodDays,omitempty"`
+	StoreMonthlyCap    int                `bson:"storeMonthlyCap,omitempty"`
+	Priority           int                `bson:"priority"`
+	Enabled            bool               `bson:"enabled"`
+	CreatedAt          time.Time          `bson:"createdAt"`
+	UpdatedAt          time.Time          `bson:"updatedAt"`
+}
+
+type rewardPolicyRequest struct {
+	Name               string `json:"name"`
+	Campaign           string `json:"campaign"`
+	Amount             int    `json:"amount"`
+	MonthlyBudget      int    `json:"monthlyBudget"`
+	ReviewerCap        int    `json:"reviewerCap"`
+	ReviewerPeriodDays int    `json:"reviewerPeriodDays"`
+	StoreMonthlyCap    int    `json:"storeMonthlyCap"`
+	Priority           int    `json:"priority"`
+	Enabled            *bool  `json:"enabled"`
+}
+
+type rewardPolicyResponse struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Campaign           string    `json:"campaign,omitempty"`
+	Amount             int       `json:"amount"`
+	MonthlyBudget      int       `json:"monthlyBudget,omitempty"`
+	ReviewerCap        int       `json:"reviewerCap,omitempty"`
+	ReviewerPeriodDays int       `json:"reviewerPeriodDays,omitempty"`
+	StoreMonthlyCap    int       `json:"storeMonthlyCap,omitempty"`
+	Priority           int       `json:"priority"`
+	Enabled            bool      `json:"enabled"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+type rewardDecision struct {
+	Amount   int
+	PolicyID primitive.ObjectID
+	Held     bool
+	HoldCode string
+	Reason   string
+}
+
+type rewardConsumption struct {
+	PolicyID primitive.ObjectID `bson:"_id"`
+	Amount   int                `bson:"amount"`
+	Count    int                `bson:"count"`
+}
+
+func rewardPolicyToResponse(doc rewardPolicyDocument) rewardPolicyResponse {
+	return rewardPolicyResponse{
+		ID:                 doc.ID.Hex(),
+		Name:               doc.Name,
+		Campaign:           doc.Campaign,
+		Amount:             doc.Amount,
+		MonthlyBudget:      doc.MonthlyBudget,
+		ReviewerCap:        doc.ReviewerCap,
+		ReviewerPeriodDays: doc.ReviewerPeriodDays,
+		StoreMonthlyCap:    doc.StoreMonthlyCap,
+		Priority:           doc.Priority,
+		Enabled:            doc.Enabled,
+		CreatedAt:          doc.CreatedAt,
+		UpdatedAt:          doc.UpdatedAt,
+	}
+}
+
+func validateRewardPolicy(req rewardPolicyRequest) (rewardPolicyRequest, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Campaign = strings.TrimSpace(req.Campaign)
+	if req.Name == "" {
+		return req, errors.New("ポリシー名は必須です")
+	}
+	if req.Amount <= 0 {
+		return req, errors.New("謝礼額は1円以上で指定してください")
+	}
+	if req.MonthlyBudget < 0 || req.ReviewerCap < 0 || req.ReviewerPeriodDays < 0 || req.StoreMonthlyCap < 0 {
+		return req, errors.New("上限値は0以上で指定してください")
+	}
+	if req.MonthlyBudget > 0 && req.MonthlyBudget < req.Amount {
+		return req, errors.New("月間予算は謝礼額以上で指定してください")
+	}
+	return req, nil
+}
+
+func monthRange(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+func formatYen(amount int) string {
+	return fmt.Sprintf("%d円", amount)
+}
+
+func (s *server) loadRewardPolicies(ctx context.Context, onlyEnabled bool) ([]rewardPolicyDocument, error) {
+	filter := bson.M{}
+	if onlyEnabled {
+		filter["enabled"] = true
+	}
+	cursor, err := s.rewardPolicies.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies := []rewardPolicyDocument{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *server) resolveRewardPolicy(ctx context.Context, review reviewDocument) (*rewardPolicyDocument, error) {
+	policies, err := s.loadRewardPolicies(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if code := reviewCampaignCode(review); code != "" {
+		for i := range policies {
+			if policies[i].Campaign == code {
+				return &policies[i], nil
+			}
+		}
+	}
+	for i := range policies {
+		if policies[i].Campaign == "" {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *server) expectedRewardAmount(ctx context.Context, review reviewDocument) int {
+	policy, err := s.resolveRewardPolicy(ctx, review)
+	if err != nil {
+		s.logger.Printf("謝礼ポリシーの取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+	}
+	if policy != nil {
+		return policy.Amount + reviewCampaignBonus(review)
+	}
+	return s.rewardDefaultAmount + reviewCampaignBonus(review)
+}
+
+// consumingRewardFilter は予算・上限を消費している謝礼に絞り込む。
+// 取り下げ・非公開・削除・却下されたレビューの未送付分は消費から外し、送付済みの分は実支出として残す。
+func consumingRewardFilter(filter bson.M) bson.M {
+	filter["reward.status"] = bson.M{"$in": rewardCommittedStatuses}
+	filter["$or"] = bson.A{
+		bson.M{"status": "approved", "deletedAt": bson.M{"$exists": false}},
+		bson.M{"reward.status": rewardStatusSent},
+	}
+	return filter
+}
+
+func (s *server) committedRewardTotal(ctx context.Context, filter bson.M) (int, int, error) {
+	cursor, err := s.reviews.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: consumingRewardFilter(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"amount": bson.M{"$sum": "$reward.amount"},
+			"count":  bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []rewardConsumption
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, 0, err
+	}
+	if len(rows) == 0 {
+		return 0, 0, nil
+	}
+	return rows[0].Amount, rows[0].Count, nil
+}
+
+func (s *server) evaluateRewardPolicy(ctx context.Context, review reviewDocument) (rewardDecision, error) {
+	return s.evaluateRewardPolicyAt(ctx, review, nil)
+}
+
+func (s *server) evaluateRewardPolicyAt(ctx context.Context, review reviewDocument, committedAt *time.Time) (rewardDecision, error) {
+	policy, err := s.resolveRewardPolicy(ctx, review)
+	if err != nil {
+		return rewardDecision{}, err
+	}
+	decision := rewardDecision{Amount: s.rewardDefaultAmount + reviewCampaignBonus(review)}
+	if policy != nil {
+		decision.Amount = policy.Amount + reviewCampaignBonus(review)
+		decision.PolicyID = policy.ID
+	}
+
+	now := time.Now().In(s.location)
+	if committedAt != nil {
+		now = committedAt.In(s.location)
+	}
+	monthStart, monthEnd := monthRange(now)
+	others := func(filter bson.M, committed bson.M) bson.M {
+		if committedAt != nil {
+			committed["$lte"] = *committedAt
+		}
+		filter["_id"] = bson.M{"$ne": review.ID}
+		filter["reward.committedAt"] = committed
+		return filter
+	}
+	hold := func(code, reason string) (rewardDecision, error) {
+		decision.Held = true
+		decision.HoldCode = code
+		decision.Reason = reason
+		return decision, nil
+	}
+
+	if s.rewardMonthlyBudget > 0 {
+		spent, _, err := s.committedRewardTotal(ctx, others(bson.M{}, bson.M{"$gte": monthStart, "$lt": monthEnd}))
+		if err != nil {
+			return rewardDecision{}, err
+		}
+		if spent+decision.Amount > s.rewardMonthlyBudget {
+			return hold(rewardHoldGlobalBudget, fmt.Sprintf("今月の謝礼予算（%s）を超えるため保留しました（消化済み%s）", formatYen(s.rewardMonthlyBudget), formatYen(spent)))
+		}
+	}
+	if policy == nil {
+		return decision, nil
+	}
+
+	if policy.MonthlyBudget > 0 {
+		spent, _, err := s.committedRewardTotal(ctx, others(bson.M{"reward.policyId": policy.ID}, bson.M{"$gte": monthStart, "$lt": monthEnd}))
+		if err != nil {
+			return rewardDecision{}, err
+		}
+		if spent+decision.Amount > policy.MonthlyBudget {
+			return hold(rewardHoldPolicyBudget, fmt.Sprintf("ポリシー「%s」の月間予算（%s）を超えるため保留しました", policy.Name, formatYen(policy.MonthlyBudget)))
+		}
+	}
+	if policy.ReviewerCap > 0 && strings.TrimSpace(review.ReviewerID) != "" {
+		days := policy.ReviewerPeriodDays
+		if days <= 0 {
+			days = defaultReviewerPeriodDays
+		}
+		_, count, err := s.committedRewardTotal(ctx, others(bson.M{"reviewerId": review.ReviewerID}, bson.M{"$gte": now.AddDate(0, 0, -days)}))
+		if err != nil {
+			return rewardDecision{}, err
+		}
+		if count >= policy.ReviewerCap {
+			return hold(rewardHoldReviewerCap, fmt.Sprintf("投稿者あたりの上限（%d日間で%d件）に達したため保留しました", days, policy.ReviewerCap))
+		}
+	}
+	if policy.StoreMonthlyCap > 0 {
+		_, count, err := s.committedRewardTotal(ctx, others(bson.M{"storeId": review.StoreID}, bson.M{"$gte": monthStart, "$lt": monthEnd}))
+		if err != nil {
+			return rewardDecision{}, err
+		}
+		if count >= policy.StoreMonthlyCap {
+			return hold(rewardHoldStoreCap, fmt.Sprintf("店舗あたりの月間上限（%d件）に達したため保留しました", policy.StoreMonthlyCap))
+		}
+	}
+	return decision, nil
+}
+
+func (d rewardDecision) update(now time.Time) bson.M {
+	update := bson.M{"reward.amount": d.Amount}
+	if !d.PolicyID.IsZero() {
+		update["reward.policyId"] = d.PolicyID
+	} else {
+		update["reward.policyId"] = nil
+	}
+	if d.Held {
+		update["reward.status"] = rewardStatusHeld
+		update["reward.holdCode"] = d.HoldCode
+		update["reward.holdReason"] = d.Reason
+		update["reward.sentAt"] = nil
+		update["reward.committedAt"] = nil
+		return update
+	}
+	update["reward.holdCode"] = ""
+	update["reward.holdReason"] = ""
+	update["reward.committedAt"] = now
+	return update
+}
+
+func rewardAboutToIssue(existing reviewDocument, status, rewardStatus string) bool {
+	if existing.Reward.CommittedAt != nil {
+		return false
+	}
+	if status == "approved" && strings.TrimSpace(existing.Status) != "approved" {
+		return true
+	}
+	return rewardStatus == rewardStatusSent && strings.TrimSpace(existing.Reward.Status) != rewardStatusSent
+}
+
+func (s *server) applyRewardDecision(ctx context.Context, review reviewDocument, update bson.M, actor string, now time.Time) (rewardDecision, error) {
+	decision, err := s.evaluateRewardPolicy(ctx, review)
+	if err != nil {
+		return decision, err
+	}
+	for key, value := range decision.update(now) {
+		update[key] = value
+	}
+	if decision.Held {
+		s.logger.Printf("reward held id=%s code=%s", review.ID.Hex(), decision.HoldCode)
+	} else {
+		s.requireRewardConfirmation(update, actor, now)
+	}
+	return decision, nil
+}
+
+func (s *server) recheckRewardCommit(ctx context.Context, review reviewDocument) reviewDocument {
+	committedAt := review.Reward.CommittedAt
+	if committedAt == nil || strings.TrimSpace(review.Reward.Status) == rewardStatusSent {
+		return review
+	}
+	decision, err := s.evaluateRewardPolicyAt(ctx, review, committedAt)
+	if err != nil {
+		s.logger.Printf("謝礼上限の再確認に失敗 id=%s err=%v", review.ID.Hex(), err)
+		return review
+	}
+	if !decision.Held {
+		return review
+	}
+	now := time.Now().In(s.location)
+	update := decision.update(now)
+	update["updatedAt"] = now
+	var held reviewDocument
+	result := s.reviews.FindOneAndUpdate(ctx, bson.M{
+		"_id":                review.ID,
+		"reward.committedAt": *committedAt,
+		"reward.status":      bson.M{"$ne": rewardStatusSent},
+	}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&held); err != nil {
+		s.logger.Printf("謝礼の再保留に失敗 id=%s err=%v", review.ID.Hex(), err)
+		return review
+	}
+	s.logger.Printf("reward held on recheck id=%s code=%s", review.ID.Hex(), decision.HoldCode)
+	return held
+}
+
+func (s *server) commitRewardIssue(ctx context.Context, review reviewDocument, actor string) (reviewDocument, error) {
+	if review.Reward.CommittedAt != nil {
+		return review, nil
+	}
+	now := time.Now().In(s.location)
+	update := bson.M{"updatedAt": now}
+	if _, err := s.applyRewardDecision(ctx, review, update, actor, now); err != nil {
+		return review, err
+	}
+	var updated reviewDocument
+	result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": review.ID, "reward.committedAt": nil}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&updated); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return review, errRewardConflict
+		}
+		return review, err
+	}
+	updated = s.recheckRewardCommit(ctx, updated)
+	if strings.TrimSpace(updated.Reward.Status) == rewardStatusHeld {
+		return updated, errRewardHeld
+	}
+	return updated, nil
+}
+
+func (s *server) adminRewardPolicyListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		policies, err := s.loadRewardPolicies(ctx, false)
+		if err != nil {
+			s.logger.Printf("admin reward policy list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの取得に失敗しました"})
+			return
+		}
+		items := make([]rewardPolicyResponse, 0, len(policies))
+		for _, policy := range policies {
+			items = append(items, rewardPolicyToResponse(policy))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminRewardPolicyCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req rewardPolicyRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		req, err := validateRewardPolicy(req)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		doc := rewardPolicyDocument{
+			ID:                 primitive.NewObjectID(),
+			Name:               req.Name,
+			Campaign:           req.Campaign,
+			Amount:             req.Amount,
+			MonthlyBudget:      req.MonthlyBudget,
+			ReviewerCap:        req.ReviewerCap,
+			ReviewerPeriodDays: req.ReviewerPeriodDays,
+			StoreMonthlyCap:    req.StoreMonthlyCap,
+			Priority:           req.Priority,
+			Enabled:            req.Enabled == nil || *req.Enabled,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if _, err := s.rewardPolicies.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("admin reward policy create failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの作成に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin reward policy create success id=%s name=%q amount=%d", doc.ID.Hex(), doc.Name, doc.Amount)
+		s.writeJSON(w, http.StatusCreated, rewardPolicyToResponse(doc))
+	}
+}
+
+func (s *server) adminRewardPolicyUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ポリシーIDの形式が不正です"})
+			return
+		}
+
+		var req rewardPolicyRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		req, err = validateRewardPolicy(req)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		update := bson.M{
+			"name":               req.Name,
+			"campaign":           req.Campaign,
+			"amount":             req.Amount,
+			"monthlyBudget":      req.MonthlyBudget,
+			"reviewerCap":        req.ReviewerCap,
+			"reviewerPeriodDays": req.ReviewerPeriodDays,
+			"storeMonthlyCap":    req.StoreMonthlyCap,
+			"priority":           req.Priority,
+			"updatedAt":          time.Now().In(s.location),
+		}
+		if req.Enabled != nil {
+			update["enabled"] = *req.Enabled
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var updated rewardPolicyDocument
+		result := s.rewardPolicies.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "謝礼ポリシーが見つかりません"})
+				return
+			}
+			s.logger.Printf("admin reward policy update failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの更新に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, rewardPolicyToResponse(updated))
+	}
+}
+
+func (s *server) adminRewardPolicyDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ポリシーIDの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		result, err := s.rewardPolicies.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			s.logger.Printf("admin reward policy delete failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの削除に失敗しました"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "謝礼ポリシーが見つかりません"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type releaseRewardRequest struct {
+	Force bool   `json:"force"`
+	Actor string `json:"actor"`
+}
+
+func (s *server) adminReviewRewardReleaseHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req releaseRewardRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		actor := adminActor(r, req.Actor)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(existing.Reward.Status) != rewardStatusHeld {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "保留中の謝礼ではありません"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		update := bson.M{"updatedAt": now}
+		decision, err := s.applyRewardDecision(ctx, existing, update, actor, now)
+		if err != nil {
+			s.logger.Printf("謝礼ポリシーの評価に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの評価に失敗しました"})
+			return
+		}
+		if decision.Held && !req.Force {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": decision.Reason})
+			return
+		}
+		if decision.Held {
+			decision.Held = false
+			for key, value := range decision.update(now) {
+				update[key] = value
+			}
+		}
+		if !s.requireRewardConfirmation(update, actor, now) {
+			update["reward.status"] = rewardStatusPending
+		}
+		update["reward.note"] = "保留を解除しました"
+
+		var updated reviewDocument
+		result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": existing.ID, "reward.status": rewardStatusHeld}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "保留中の謝礼ではありません"})
+				return
+			}
+			s.logger.Printf("謝礼保留の解除に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼保留の解除に失敗しました"})
+			return
+		}
+		if !req.Force {
+			updated = s.recheckRewardCommit(ctx, updated)
+			if strings.TrimSpace(updated.Reward.Status) == rewardStatusHeld {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": updated.Reward.HoldReason})
+				return
+			}
+		}
+		s.recordReviewAudit(ctx, updated.ID, "reward_release", actor, auditActorAdmin, bson.M{"force": req.Force, "holdCode": existing.Reward.HoldCode})
+
+		store, err := s.getStoreByID(ctx, updated.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		if strings.TrimSpace(updated.Status) == "approved" {
+			go s.autoIssueReviewReward(updated)
+		}
+		s.logger.Printf("admin reward release id=%s force=%t", updated.ID.Hex(), req.Force)
+		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
+	}
+}
+
+func (s *server) adminRewardBudgetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month := time.Now().In(s.location)
+		if raw := strings.TrimSpace(r.URL.Query().Get("month")); raw != "" {
+			parsed, err := time.ParseInLocation("2006-01", raw, s.location)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "monthはYYYY-MM形式で指定してください"})
+				return
+			}
+			month = parsed
+		}
+		monthStart, monthEnd := monthRange(month)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := s.reviews.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: consumingRewardFilter(bson.M{
+				"reward.committedAt": bson.M{"$gte": monthStart, "$lt": monthEnd},
+			})}},
+			{{Key: "$group", Value: bson.M{
+				"_id":    "$reward.policyId",
+				"amount": bson.M{"$sum": "$reward.amount"},
+				"count":  bson.M{"$sum": 1},
+			}}},
+		})
+		if err != nil {
+			s.logger.Printf("admin reward budget aggregate failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼予算の集計に失敗しました"})
+			return
+		}
+		var rows []rewardConsumption
+		if err := cursor.All(ctx, &rows); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼予算の集計に失敗しました"})
+			return
+		}
+
+		policies, err := s.loadRewardPolicies(ctx, false)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼ポリシーの取得に失敗しました"})
+			return
+		}
+		consumed := make(map[primitive.ObjectID]rewardConsumption, len(rows))
+		totalAmount, totalCount := 0, 0
+		for _, row := range rows {
+			consumed[row.PolicyID] = row
+			totalAmount += row.Amount
+			totalCount += row.Count
+		}
+
+		items := make([]map[string]any, 0, len(policies)+1)
+		for _, policy := range policies {
+			row := consumed[policy.ID]
+			item := map[string]any{
+				"policy":   rewardPolicyToResponse(policy),
+				"consumed": row.Amount,
+				"count":    row.Count,
+			}
+			if policy.MonthlyBudget > 0 {
+				item["remaining"] = policy.MonthlyBudget - row.Amount
+			}
+			items = append(items, item)
+		}
+		if row, ok := consumed[primitive.NilObjectID]; ok {
+			items = append(items, map[string]any{"policy": nil, "consumed": row.Amount, "count": row.Count})
+		}
+
+		heldCursor, err := s.reviews.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"reward.status": rewardStatusHeld}}},
+			{{Key: "$group", Value: bson.M{"_id": "$reward.holdCode", "count": bson.M{"$sum": 1}}}},
+		})
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "保留中の謝礼の集計に失敗しました"})
+			return
+		}
+		var heldRows []struct {
+			Code  string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err := heldCursor.All(ctx, &heldRows); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "保留中の謝礼の集計に失敗しました"})
+			return
+		}
+		held := map[string]int{}
+		for _, row := range heldRows {
+			held[row.Code] = row.Count
+		}
+
+		total := map[string]any{"consumed": totalAmount, "count": totalCount}
+		if s.rewardMonthlyBudget > 0 {
+			total["budget"] = s.rewardMonthlyBudget
+			total["remaining"] = s.rewardMonthlyBudget - totalAmount
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"month":    monthStart.Format("2006-01"),
+			"total":    total,
+			"policies": items,
+			"held":     held,
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRewardAboutToIssue(t *testing.T) {
+	committedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name         string
+		existing     reviewDocument
+		status       string
+		rewardStatus string
+		want         bool
+	}{
+		{"承認", reviewDocument{Status: "pending"}, "approved", "", true},
+		{"差し戻しから承認", reviewDocument{Status: reviewStatusNeedsChanges}, "approved", "", true},
+		{"承認済みの再承認", reviewDocument{Status: "approved"}, "approved", "", false},
+		{"却下", reviewDocument{Status: "pending"}, "rejected", "", false},
+		{"手動で送付済み", reviewDocument{Status: "approved", Reward: reviewRewardDocument{Status: rewardStatusPending}}, "", rewardStatusSent, true},
+		{"送付済みの再送付", reviewDocument{Status: "approved", Reward: reviewRewardDocument{Status: rewardStatusSent}}, "", rewardStatusSent, false},
+		{"確定済みの承認", reviewDocument{Status: "pending", Reward: reviewRewardDocument{CommittedAt: &committedAt}}, "approved", "", false},
+		{"確定済みの送付", reviewDocument{Status: "approved", Reward: reviewRewardDocument{CommittedAt: &committedAt}}, "", rewardStatusSent, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rewardAboutToIssue(tc.existing, tc.status, tc.rewardStatus); got != tc.want {
+				t.Errorf("rewardAboutToIssue() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConsumingRewardFilter(t *testing.T) {
+	got := consumingRewardFilter(bson.M{"reviewerId": "u1"})
+	want := bson.M{
+		"reviewerId":    "u1",
+		"reward.status": bson.M{"$in": rewardCommittedStatuses},
+		"$or": bson.A{
+			bson.M{"status": "approved", "deletedAt": bson.M{"$exists": false}},
+			bson.M{"reward.status": rewardStatusSent},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("consumingRewardFilter() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ledgerEventReconciled = "reconciled"
+
+	maxReconcileBody = 5 << 20
+	maxReconcileRows = 5000
+)
+
+var rewardExportHeader = []string{"reviewId", "storeId", "store", "reviewerHandle", "reviewerId", "amount", "rewardStatus", "committedAt", "sentAt"}
+
+var reconcileTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006/01/02 15:04", "2006-01-02", "2006/01/02"}
+
+type reconcileRewardsRequest struct {
+	CSV    string `json:"csv"`
+	Actor  string `json:"actor"`
+	DryRun bool   `json:"dryRun"`
+}
+
+type reconcileMismatch struct {
+	Line     int    `json:"line"`
+	ReviewID string `json:"reviewId,omitempty"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+type reconcileRow struct {
+	line     int
+	reviewID primitive.ObjectID
+	rawID    string
+	amount   int
+	sentAt   *time.Time
+}
+
+func parseExportPeriod(query url.Values, loc *time.Location) (time.Time, time.Time, error) {
+	from, to := strings.TrimSpace(query.Get("from")), strings.TrimSpace(query.Get("to"))
+	if from == "" && to == "" {
+		month := time.Now().In(loc)
+		if raw := strings.TrimSpace(query.Get("month")); raw != "" {
+			parsed, err := time.ParseInLocation("2006-01", raw, loc)
+			if err != nil {
+				return time.Time{}, time.Time{}, errors.New("monthはYYYY-MM形式で指定してください")
+			}
+			month = parsed
+		}
+		start, end := monthRange(month)
+		return start, end, nil
+	}
+	if from == "" || to == "" {
+		return time.Time{}, time.Time{}, errors.New("fromとtoは両方指定してください")
+	}
+	start, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("fromはYYYY-MM-DD形式で指定してください")
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("toはYYYY-MM-DD形式で指定してください")
+	}
+	end = end.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("toはfrom以降の日付を指定してください")
+	}
+	return start, end, nil
+}
+
+func formatExportTime(t *time.Time, loc *time.Location) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+func spreadsheetSafe(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+func (s *server) adminRewardExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		format := strings.ToLower(strings.TrimSpace(query.Get("format")))
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "xlsx" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "formatはcsvまたはxlsxを指定してください"})
+			return
+		}
+		start, end, err := parseExportPeriod(query, s.location)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		statuses := rewardCommittedStatuses
+		if raw := strings.TrimSpace(query.Get("status")); raw != "" {
+			statuses = nil
+			for _, status := range strings.Split(raw, ",") {
+				if status = strings.TrimSpace(status); status != "" {
+					statuses = append(statuses, status)
+				}
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		period := bson.M{"$gte": start, "$lt": end}
+		filter := bson.M{
+			"reward.status": bson.M{"$in": statuses},
+			"$or": bson.A{
+				bson.M{"reward.sentAt": period},
+				bson.M{"reward.committedAt": period},
+			},
+		}
+		cursor, err := s.reviews.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "reward.committedAt", Value: 1}, {Key: "_id", Value: 1}}))
+		if err != nil {
+			s.logger.Printf("admin reward export failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼データの取得に失敗しました"})
+			return
+		}
+		var reviews []reviewDocument
+		if err := cursor.All(ctx, &reviews); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "謝礼データの取得に失敗しました"})
+			return
+		}
+		storeIDs := make([]primitive.ObjectID, 0, len(reviews))
+		for _, review := range reviews {
+			storeIDs = append(storeIDs, review.StoreID)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		rows := make([][]any, 0, len(reviews)+1)
+		header := make([]any, 0, len(rewardExportHeader))
+		for _, column := range rewardExportHeader {
+			header = append(header, column)
+		}
+		rows = append(rows, header)
+		for _, review := range reviews {
+			rows = append(rows, []any{
+				review.ID.Hex(),
+				review.StoreID.Hex(),
+				spreadsheetSafe(storeLabel(stores[review.StoreID])),
+				spreadsheetSafe(strings.TrimSpace(review.ReviewerUsername)),
+				spreadsheetSafe(strings.TrimSpace(review.ReviewerID)),
+				review.Reward.Amount,
+				strings.TrimSpace(review.Reward.Status),
+				formatExportTime(review.Reward.CommittedAt, s.location),
+				formatExportTime(review.Reward.SentAt, s.location),
+			})
+		}
+
+		filename := fmt.Sprintf("rewards_%s_%s.%s", start.Format("20060102"), end.AddDate(0, 0, -1).Format("20060102"), format)
+		var buf bytes.Buffer
+		if format == "xlsx" {
+			if err := writeXLSX(&buf, "rewards", rows); err != nil {
+				s.logger.Printf("admin reward export xlsx failed: %v", err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "エクスポートの生成に失敗しました"})
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		} else {
+			buf.WriteString("\ufeff")
+			writer := csv.NewWriter(&buf)
+			for _, row := range rows {
+				record := make([]string, len(row))
+				for i, value := range row {
+					record[i] = fmt.Sprint(value)
+				}
+				_ = writer.Write(record)
+			}
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				s.logger.Printf("admin reward export csv failed: %v", err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "エクスポートの生成に失敗しました"})
+				return
+			}
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			s.logger.Printf("admin reward export write failed: %v", err)
+		}
+		s.logger.Printf("admin reward export format=%s rows=%d from=%s to=%s", format, len(reviews), start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+}
+
+func parseReconcileCSV(text string, loc *time.Location) ([]reconcileRow, []reconcileMismatch, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(text, "\ufeff")))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, errors.New("CSVの形式が不正です")
+	}
+	if len(records) < 2 {
+		return nil, nil, errors.New("CSVにデータ行がありません")
+	}
+	if len(records)-1 > maxReconcileRows {
+		return nil, nil, fmt.Errorf("一度に取り込める行は%d件までです", maxReconcileRows)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idColumn, ok := columns["reviewid"]
+	if !ok {
+		return nil, nil, errors.New("CSVのヘッダーにreviewId列がありません")
+	}
+	cell := func(record []string, name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	rows := make([]reconcileRow, 0, len(records)-1)
+	var mismatches []reconcileMismatch
+	seen := make(map[primitive.ObjectID]int)
+	for i, record := range records[1:] {
+		line := i + 2
+		if idColumn >= len(record) || strings.TrimSpace(record[idColumn]) == "" {
+			continue
+		}
+		rawID := strings.TrimSpace(record[idColumn])
+		reviewID, err := primitive.ObjectIDFromHex(rawID)
+		if err != nil {
+			mismatches = append(mismatches, reconcileMismatch{Line: line, ReviewID: rawID, Code: "invalid_review_id", Message: "レビューIDの形式が不正です"})
+			continue
+		}
+		if first, ok := seen[reviewID]; ok {
+			mismatches = append(mismatches, reconcileMismatch{Line: line, ReviewID: rawID, Code: "duplicate_row", Message: fmt.Sprintf("%d行目と重複しています", first)})
+			continue
+		}
+		seen[reviewID] = line
+
+		row := reconcileRow{line: line, reviewID: reviewID, rawID: rawID}
+		if raw := strings.ReplaceAll(strings.TrimSuffix(cell(record, "amount"), "円"), ",", ""); raw != "" {
+			amount, err := strconv.Atoi(raw)
+			if err != nil || amount < 0 {
+				mismatches = append(mismatches, reconcileMismatch{Line: line, ReviewID: rawID, Code: "invalid_amount", Message: "金額の形式が不正です", Actual: raw})
+				continue
+			}
+			row.amount = amount
+		}
+		if raw := cell(record, "sentat"); raw != "" {
+			var parsed time.Time
+			for _, layout := range reconcileTimeLayouts {
+				if parsed, err = time.ParseInLocation(layout, raw, loc); err == nil {
+					break
+				}
+			}
+			if err != nil {
+				mismatches = append(mismatches, reconcileMismatch{Line: line, ReviewID: rawID, Code: "invalid_sent_at", Message: "送付日時の形式が不正です", Actual: raw})
+				continue
+			}
+			row.sentAt = &parsed
+		}
+		rows = append(rows, row)
+	}
+	return rows, mismatches, nil
+}
+
+func (s *server) adminRewardReconcileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req reconcileRewardsRequest
+		body := io.LimitReader(r.Body, maxReconcileBody)
+		if strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "text/csv") {
+			raw, err := io.ReadAll(body)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの読み込みに失敗しました"})
+				return
+			}
+			req.CSV = string(raw)
+			req.Actor = r.URL.Query().Get("actor")
+			req.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dryRun"))
+		} else if err := json.NewDecoder(body).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		actor := adminActor(r, req.Actor)
+
+		rows, mismatches, err := parseReconcileCSV(req.CSV, s.location)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+		defer cancel()
+
+		ids := make([]primitive.ObjectID, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.reviewID)
+		}
+		cursor, err := s.reviews.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			s.logger.Printf("admin reward reconcile lookup failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+			return
+		}
+		var found []reviewDocument
+		if err := cursor.All(ctx, &found); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+			return
+		}
+		reviews := make(map[primitive.ObjectID]reviewDocument, len(found))
+		storeIDs := make([]primitive.ObjectID, 0, len(found))
+		for _, review := range found {
+			reviews[review.ID] = review
+			storeIDs = append(storeIDs, review.StoreID)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		batchID := primitive.NewObjectID().Hex()
+		marked, alreadySent, total := 0, 0, 0
+		for _, row := range rows {
+			review, ok := reviews[row.reviewID]
+			if !ok {
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "review_not_found", Message: "レビューが見つかりません"})
+				continue
+			}
+			rewardStatus := strings.TrimSpace(review.Reward.Status)
+			if rewardStatus == rewardStatusSent {
+				alreadySent++
+				if row.amount > 0 && review.Reward.Amount > 0 && row.amount != review.Reward.Amount {
+					mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "amount_mismatch", Message: "送付済みの金額と一致しません", Expected: strconv.Itoa(review.Reward.Amount), Actual: strconv.Itoa(row.amount)})
+				}
+				continue
+			}
+			if !rewardEligible(review) {
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "not_payable", Message: "承認済みで支払い対象の謝礼ではありません", Expected: rewardStatusPending, Actual: rewardStatus})
+				continue
+			}
+			if review.Reward.CommittedAt == nil {
+				if req.DryRun {
+					decision, err := s.evaluateRewardPolicy(ctx, review)
+					if err != nil {
+						s.logger.Printf("謝礼ポリシーの評価に失敗 id=%s err=%v", review.ID.Hex(), err)
+						mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "update_failed", Message: "謝礼ポリシーの評価に失敗しました"})
+						continue
+					}
+					if decision.Held {
+						mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "reward_held", Message: decision.Reason})
+						continue
+					}
+					review.Reward.Amount = decision.Amount
+				} else {
+					committed, err := s.commitRewardIssue(ctx, review, actor)
+					switch {
+					case errors.Is(err, errRewardHeld):
+						mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "reward_held", Message: committed.Reward.HoldReason})
+						continue
+					case errors.Is(err, errRewardConflict):
+						mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "conflict", Message: "処理中に謝礼の状態が変更されました"})
+						continue
+					case err != nil:
+						s.logger.Printf("謝礼ポリシーの適用に失敗 id=%s err=%v", review.ID.Hex(), err)
+						mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "update_failed", Message: "謝礼ポリシーの適用に失敗しました"})
+						continue
+					}
+					review = committed
+				}
+			}
+			if !s.rewardConfirmed(review) {
+				if !req.DryRun && strings.TrimSpace(review.Reward.Status) != rewardStatusAwaiting {
+					s.requestRewardConfirmation(ctx, review, actor)
+				}
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "awaiting_confirmation", Message: errRewardUnconfirmed.Error()})
+				continue
+			}
+			if !rewardEligible(review) {
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "not_payable", Message: "承認済みで支払い対象の謝礼ではありません", Expected: rewardStatusPending, Actual: strings.TrimSpace(review.Reward.Status)})
+				continue
+			}
+			if row.amount > 0 && review.Reward.Amount > 0 && row.amount != review.Reward.Amount {
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "amount_mismatch", Message: "謝礼額が一致しません", Expected: strconv.Itoa(review.Reward.Amount), Actual: strconv.Itoa(row.amount)})
+				continue
+			}
+
+			amount := review.Reward.Amount
+			if amount == 0 {
+				amount = row.amount
+			}
+			sentAt := now
+			if row.sentAt != nil {
+				sentAt = *row.sentAt
+			}
+			if req.DryRun {
+				marked++
+				total += amount
+				continue
+			}
+			update := bson.M{
+				"reward.status": rewardStatusSent,
+				"reward.sentAt": sentAt,
+				"reward.amount": amount,
+				"reward.note":   "照合取込で送付済みに更新しました",
+				"updatedAt":     now,
+			}
+			var updated reviewDocument
+			result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": review.ID, "reward.status": bson.M{"$in": rewardPayableStatuses}}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+			if err := result.Decode(&updated); err != nil {
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "conflict", Message: "処理中に謝礼の状態が変更されました"})
+					continue
+				}
+				s.logger.Printf("謝礼の照合更新に失敗 id=%s err=%v", review.ID.Hex(), err)
+				mismatches = append(mismatches, reconcileMismatch{Line: row.line, ReviewID: row.rawID, Code: "update_failed", Message: "謝礼の更新に失敗しました"})
+				continue
+			}
+			if review.Reward.LinkID != nil {
+				if _, err := s.claimRewardLink(ctx, *review.Reward.LinkID, review.ID, sentAt); err != nil {
+					s.logger.Printf("報酬リンクの送信済み更新に失敗 linkId=%s err=%v", review.Reward.LinkID.Hex(), err)
+				}
+			}
+			s.handleReviewStatusTransition(ctx, review, updated, stores[review.StoreID])
+			marked++
+			total += amount
+			s.recordRewardLedger(ctx, rewardLedgerDocument{
+				Event:      ledgerEventReconciled,
+				LinkID:     review.Reward.LinkID,
+				ReviewID:   &review.ID,
+				ReviewerID: review.ReviewerID,
+				Requester:  review.Reward.RequestedBy,
+				Confirmer:  review.Reward.ConfirmedBy,
+				Amount:     amount,
+				Actor:      actor,
+				Detail:     "batch=" + batchID,
+			})
+		}
+
+		s.logger.Printf("admin reward reconcile batchId=%s rows=%d marked=%d alreadySent=%d mismatches=%d dryRun=%t", batchID, len(rows), marked, alreadySent, len(mismatches), req.DryRun)
+		if mismatches == nil {
+			mismatches = []reconcileMismatch{}
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"batchId":     batchID,
+			"dryRun":      req.DryRun,
+			"rows":        len(rows),
+			"marked":      marked,
+			"markedTotal": total,
+			"alreadySent": alreadySent,
+			"mismatches":  mismatches,
+		})
+	}
+}
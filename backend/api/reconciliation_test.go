@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReconcileCSV(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	const (
+		idA = "64b000000000000000000001"
+		idB = "64b000000000000000000002"
+	)
+	cases := []struct {
+		name       string
+		text       string
+		wantIDs    []string
+		wantAmount []int
+		wantSentAt []string
+		wantCodes  []string
+		wantErr    bool
+	}{
+		{
+			name:       "書き出したCSVをそのまま取り込む",
+			text:       "\ufeffreviewId,store,amount,sentAt\n" + idA + ",店舗A,1000,2026-10-01 12:00:00\n" + idB + ",店舗B,500,\n",
+			wantIDs:    []string{idA, idB},
+			wantAmount: []int{1000, 500},
+			wantSentAt: []string{"2026-10-01T12:00:00+09:00", ""},
+		},
+		{
+			name:       "列名の大小と金額表記の揺れ",
+			text:       "ReviewID,Amount,SentAt\n" + idA + ",\"1,000円\",2026/10/02\n",
+			wantIDs:    []string{idA},
+			wantAmount: []int{1000},
+			wantSentAt: []string{"2026-10-02T00:00:00+09:00"},
+		},
+		{
+			name:       "空行と空のIDは読み飛ばす",
+			text:       "reviewId,amount\n,100\n\n" + idA + ",\n",
+			wantIDs:    []string{idA},
+			wantAmount: []int{0},
+			wantSentAt: []string{""},
+		},
+		{
+			name:       "行ごとの不一致",
+			text:       "reviewId,amount,sentAt\nbad-id,1,\n" + idA + ",abc,\n" + idB + ",-5,\n64b000000000000000000003,1,yesterday\n64b000000000000000000004,1,\n64b000000000000000000004,1,\n",
+			wantIDs:    []string{"64b000000000000000000004"},
+			wantCodes:  []string{"invalid_review_id", "invalid_amount", "invalid_amount", "invalid_sent_at", "duplicate_row"},
+			wantAmount: []int{1},
+			wantSentAt: []string{""},
+		},
+		{name: "reviewId列なし", text: "id,amount\n" + idA + ",1\n", wantErr: true},
+		{name: "ヘッダーのみ", text: "reviewId,amount\n", wantErr: true},
+		{name: "CSVの形式不正", text: "reviewId\n\"" + idA + "\n", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, mismatches, err := parseReconcileCSV(tc.text, loc)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseReconcileCSV() err = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			var ids, sentAt []string
+			var amounts []int
+			for _, row := range rows {
+				ids = append(ids, row.reviewID.Hex())
+				amounts = append(amounts, row.amount)
+				if row.sentAt != nil {
+					sentAt = append(sentAt, row.sentAt.Format(time.RFC3339))
+				} else {
+					sentAt = append(sentAt, "")
+				}
+			}
+			var codes []string
+			for _, mismatch := range mismatches {
+				codes = append(codes, mismatch.Code)
+			}
+			if !reflect.DeepEqual(ids, tc.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tc.wantIDs)
+			}
+			if !reflect.DeepEqual(amounts, tc.wantAmount) {
+				t.Errorf("amounts = %v, want %v", amounts, tc.wantAmount)
+			}
+			if !reflect.DeepEqual(sentAt, tc.wantSentAt) {
+				t.Errorf("sentAt = %v, want %v", sentAt, tc.wantSentAt)
+			}
+			if !reflect.DeepEqual(codes, tc.wantCodes) {
+				t.Errorf("mismatch codes = %v, want %v", codes, tc.wantCodes)
+			}
+		})
+	}
+}
+
+func TestSpreadsheetSafe(t *testing.T) {
+	cases := map[string]string{
+		"":             "",
+		"店舗A":          "店舗A",
+		"=HYPERLINK()": "'=HYPERLINK()",
+		"+81":          "'+81",
+		"-1":           "'-1",
+		"@sum":         "'@sum",
+		"\tcmd":        "'\tcmd",
+		"\rcmd":        "'\rcmd",
+		"a=b":          "a=b",
+	}
+	for input, want := range cases {
+		if got := spreadsheetSafe(input); got != want {
+			t.Errorf("spreadsheetSafe(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
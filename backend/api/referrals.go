@@ -0,0 +1,460 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	referralStatusPending   = "pending"
+	referralStatusQualified = "qualified"
+	referralStatusRejected  = "rejected"
+
+	referralRejectLinkedIdentity = "linked_identity"
+	referralRejectReviewRejected = "review_rejected"
+
+	ledgerEventReferralBonus = "referral_bonus"
+
+	referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referralCodeLength   = 8
+)
+
+var (
+	errReferralCodeUnknown   = errors.New("紹介コードが見つかりません")
+	errReferralSelf          = errors.New("自分の紹介コードは利用できません")
+	errReferralNotFirst      = errors.New("紹介コードは初回の投稿時のみ利用できます")
+	errReferralCapReached    = errors.New("この紹介コードは利用上限に達しています")
+	errReferralAlreadyRedeem = errors.New("紹介コードは既に利用済みです")
+)
+
+type referralCodeDocument struct {
+	UserID    string    `bson:"_id"`
+	Code      string    `bson:"code"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type referralDocument struct {
+	ID           primitive.ObjectID  `bson:"_id"`
+	Code         string              `bson:"code"`
+	ReferrerID   string              `bson:"referrerId"`
+	RefereeID    string              `bson:"refereeId"`
+	ReviewID     primitive.ObjectID  `bson:"reviewId"`
+	Status       string              `bson:"status"`
+	RejectReason string              `bson:"rejectReason,omitempty"`
+	BonusAmount  int                 `bson:"bonusAmount,omitempty"`
+	LedgerID     *primitive.ObjectID `bson:"ledgerId,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt"`
+	QualifiedAt  *time.Time          `bson:"qualifiedAt,omitempty"`
+}
+
+type referralResponse struct {
+	ID           string     `json:"id"`
+	Code         string     `json:"code"`
+	ReferrerID   string     `json:"referrerId,omitempty"`
+	RefereeID    string     `json:"refereeId,omitempty"`
+	ReviewID     string     `json:"reviewId,omitempty"`
+	Status       string     `json:"status"`
+	RejectReason string     `json:"rejectReason,omitempty"`
+	BonusAmount  int        `json:"bonusAmount,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	QualifiedAt  *time.Time `json:"qualifiedAt,omitempty"`
+}
+
+type referralStats struct {
+	Total     int `json:"total" bson:"total"`
+	Pending   int `json:"pending" bson:"pending"`
+	Qualified int `json:"qualified" bson:"qualified"`
+	Rejected  int `json:"rejected" bson:"rejected"`
+	Earned    int `json:"earned" bson:"earned"`
+}
+
+func referralToResponse(doc referralDocument, admin bool) referralResponse {
+	response := referralResponse{
+		ID:          doc.ID.Hex(),
+		Code:        doc.Code,
+		Status:      doc.Status,
+		BonusAmount: doc.BonusAmount,
+		CreatedAt:   doc.CreatedAt,
+		QualifiedAt: doc.QualifiedAt,
+	}
+	if admin {
+		response.ReferrerID = doc.ReferrerID
+		response.RefereeID = doc.RefereeID
+		response.ReviewID = doc.ReviewID.Hex()
+		response.RejectReason = doc.RejectReason
+	}
+	return response
+}
+
+func generateReferralCode() (string, error) {
+	buf := make([]byte, referralCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := make([]byte, referralCodeLength)
+	for i, b := range buf {
+		code[i] = referralCodeAlphabet[int(b)%len(referralCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *server) ensureReferralIndexes(ctx context.Context) error {
+	if _, err := s.referralCodes.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	_, err := s.referrals.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refereeId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "referrerId", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	return err
+}
+
+func (s *server) referralCodeForUser(ctx context.Context, userID string) (referralCodeDocument, error) {
+	var doc referralCodeDocument
+	err := s.referralCodes.FindOne(ctx, bson.M{"_id": userID}).Decode(&doc)
+	if err == nil {
+		return doc, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return doc, err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := generateReferralCode()
+		if err != nil {
+			return doc, err
+		}
+		doc = referralCodeDocument{UserID: userID, Code: code, CreatedAt: time.Now().In(s.location)}
+		_, err = s.referralCodes.InsertOne(ctx, doc)
+		if err == nil {
+			return doc, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return doc, err
+		}
+		if existing := s.referralCodes.FindOne(ctx, bson.M{"_id": userID}).Decode(&doc); existing == nil {
+			return doc, nil
+		}
+	}
+	return doc, errors.New("紹介コードの発行に失敗しました")
+}
+
+func (s *server) linkedIdentity(ctx context.Context, referrerID string, referee authenticatedUser) (bool, error) {
+	emails := map[string]string{}
+	cursor, err := s.userEmails.Find(ctx, bson.M{
+		"_id":        bson.M{"$in": bson.A{referrerID, referee.ID}},
+		"verifiedAt": bson.M{"$ne": nil},
+	})
+	if err != nil {
+		return false, err
+	}
+	var docs []userEmailDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return false, err
+	}
+	for _, doc := range docs {
+		emails[doc.UserID] = strings.ToLower(strings.TrimSpace(doc.Email))
+	}
+	if email := emails[referrerID]; email != "" && email == emails[referee.ID] {
+		return true, nil
+	}
+
+	username := strings.ToLower(strings.TrimSpace(referee.Username))
+	if username == "" {
+		return false, nil
+	}
+	var previous reviewDocument
+	err = s.reviews.FindOne(ctx, bson.M{"reviewerId": referrerID}, options.FindOne().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetProjection(bson.M{"reviewerUsername": 1})).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.ToLower(strings.TrimSpace(previous.ReviewerUsername)) == username, nil
+}
+
+func (s *server) prepareReferral(ctx context.Context, user authenticatedUser, rawCode string) (*referralDocument, error) {
+	code := normalizeReferralCode(rawCode)
+	if code == "" {
+		return nil, nil
+	}
+
+	var owner referralCodeDocument
+	if err := s.referralCodes.FindOne(ctx, bson.M{"code": code}).Decode(&owner); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errReferralCodeUnknown
+		}
+		return nil, err
+	}
+	if owner.UserID == user.ID {
+		return nil, errReferralSelf
+	}
+
+	submitted, err := s.reviews.CountDocuments(ctx, bson.M{"reviewerId": user.ID})
+	if err != nil {
+		return nil, err
+	}
+	if submitted > 0 {
+		return nil, errReferralNotFirst
+	}
+	if err := s.referrals.FindOne(ctx, bson.M{"refereeId": user.ID}).Err(); err == nil {
+		return nil, errReferralAlreadyRedeem
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	used, err := s.referrals.CountDocuments(ctx, bson.M{
+		"referrerId": owner.UserID,
+		"status":     bson.M{"$in": bson.A{referralStatusPending, referralStatusQualified}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if int(used) >= s.referralMaxPerUser {
+		return nil, errReferralCapReached
+	}
+
+	doc := &referralDocument{
+		ID:         primitive.NewObjectID(),
+		Code:       code,
+		ReferrerID: owner.UserID,
+		RefereeID:  user.ID,
+		Status:     referralStatusPending,
+		CreatedAt:  time.Now().In(s.location),
+	}
+	linked, err := s.linkedIdentity(ctx, owner.UserID, user)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		doc.Status = referralStatusRejected
+		doc.RejectReason = referralRejectLinkedIdentity
+		s.logger.Printf("紹介コードの自己利用の疑い referrer=%s referee=%s", owner.UserID, user.ID)
+	}
+	return doc, nil
+}
+
+func (s *server) saveReferral(ctx context.Context, referral *referralDocument, reviewID primitive.ObjectID) {
+	if referral == nil {
+		return
+	}
+	referral.ReviewID = reviewID
+	if _, err := s.referrals.InsertOne(ctx, referral); err != nil {
+		s.logger.Printf("紹介の保存に失敗 referrer=%s referee=%s err=%v", referral.ReferrerID, referral.RefereeID, err)
+	}
+}
+
+func (s *server) settleReferral(ctx context.Context, review reviewDocument) {
+	now := time.Now().In(s.location)
+	switch strings.TrimSpace(review.Status) {
+	case "approved":
+		var referral referralDocument
+		err := s.referrals.FindOneAndUpdate(ctx, bson.M{"reviewId": review.ID, "status": referralStatusPending}, bson.M{"$set": bson.M{
+			"status":      referralStatusQualified,
+			"bonusAmount": s.referralBonusAmount,
+			"qualifiedAt": now,
+		}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&referral)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				s.logger.Printf("紹介の確定に失敗 reviewId=%s err=%v", review.ID.Hex(), err)
+			}
+			return
+		}
+		ledger := rewardLedgerDocument{
+			ID:         primitive.NewObjectID(),
+			Event:      ledgerEventReferralBonus,
+			ReviewID:   &review.ID,
+			ReviewerID: referral.ReferrerID,
+			Amount:     referral.BonusAmount,
+			Actor:      "referral",
+			Detail:     "referral=" + referral.ID.Hex(),
+			CreatedAt:  now,
+		}
+		if _, err := s.rewardLedger.InsertOne(ctx, ledger); err != nil {
+			s.logger.Printf("紹介ボーナスの台帳記録に失敗 referral=%s err=%v", referral.ID.Hex(), err)
+		} else if _, err := s.referrals.UpdateByID(ctx, referral.ID, bson.M{"$set": bson.M{"ledgerId": ledger.ID}}); err != nil {
+			s.logger.Printf("紹介ボーナスの台帳IDの保存に失敗 referral=%s err=%v", referral.ID.Hex(), err)
+		}
+		s.notifyReviewer(ctx, referral.ReferrerID, notification{
+			Subject:        "紹介ボーナスが確定しました",
+			Text:           fmt.Sprintf("ご紹介いただいた方のアンケートが承認されました。紹介ボーナス%d円分を後日お送りします。", referral.BonusAmount),
+			IdempotencyKey: "referral-qualified:" + referral.ID.Hex(),
+		})
+	case "rejected", reviewStatusWithdrawn:
+		if _, err := s.referrals.UpdateOne(ctx, bson.M{"reviewId": review.ID, "status": referralStatusPending}, bson.M{"$set": bson.M{
+			"status":       referralStatusRejected,
+			"rejectReason": referralRejectReviewRejected,
+		}}); err != nil {
+			s.logger.Printf("紹介の失効に失敗 reviewId=%s err=%v", review.ID.Hex(), err)
+		}
+	}
+}
+
+func (s *server) loadReferralStats(ctx context.Context, match bson.M) (map[string]referralStats, error) {
+	cursor, err := s.referrals.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$referrerId",
+			"total":     bson.M{"$sum": 1},
+			"pending":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", referralStatusPending}}, 1, 0}}},
+			"qualified": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", referralStatusQualified}}, 1, 0}}},
+			"rejected":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", referralStatusRejected}}, 1, 0}}},
+			"earned":    bson.M{"$sum": "$bonusAmount"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ReferrerID string        `bson:"_id"`
+		Stats      referralStats `bson:",inline"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	stats := make(map[string]referralStats, len(rows))
+	for _, row := range rows {
+		stats[row.ReferrerID] = row.Stats
+	}
+	return stats, nil
+}
+
+func (s *server) myReferralHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		code, err := s.referralCodeForUser(ctx, user.ID)
+		if err != nil {
+			s.logger.Printf("紹介コードの取得に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "紹介コードの取得に失敗しました"})
+			return
+		}
+		stats, err := s.loadReferralStats(ctx, bson.M{"referrerId": user.ID})
+		if err != nil {
+			s.logger.Printf("紹介実績の集計に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "紹介実績の取得に失敗しました"})
+			return
+		}
+
+		cursor, err := s.referrals.Find(ctx, bson.M{"referrerId": user.ID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(50))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "紹介実績の取得に失敗しました"})
+			return
+		}
+		var docs []referralDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "紹介実績の取得に失敗しました"})
+			return
+		}
+		items := make([]referralResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, referralToResponse(doc, false))
+		}
+
+		userStats := stats[user.ID]
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"code":        code.Code,
+			"bonusAmount": s.referralBonusAmount,
+			"limit":       s.referralMaxPerUser,
+			"remaining":   max(s.referralMaxPerUser-userStats.Pending-userStats.Qualified, 0),
+			"stats":       userStats,
+			"items":       items,
+		})
+	}
+}
+
+func (s *server) adminReferralReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		match := bson.M{}
+		if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" && status != "all" {
+			match["status"] = status
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		stats, err := s.loadReferralStats(ctx, match)
+		if err != nil {
+			s.logger.Printf("admin referral report failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "紹介実績の集計に失敗しました"})
+			return
+		}
+		referrers := make([]map[string]any, 0, len(stats))
+		totals := referralStats{}
+		for referrerID, row := range stats {
+			referrers = append(referrers, map[string]any{"referrerId": referrerID, "stats": row})
+			totals.Total += row.Total
+			totals.Pending += row.Pending
+			totals.Qualified += row.Qualified
+			totals.Rejected += row.Rejected
+			totals.Earned += row.Earned
+		}
+		sort.Slice(referrers, func(i, j int) bool {
+			a, b := referrers[i]["stats"].(referralStats), referrers[j]["stats"].(referralStats)
+			if a.Qualified != b.Qualified {
+				return a.Qualified > b.Qualified
+			}
+			return a.Total > b.Total
+		})
+
+		cursor, err := s.referrals.Find(ctx, bson.M{"status": referralStatusRejected, "rejectReason": referralRejectLinkedIdentity}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(100))
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "不正疑いの紹介の取得に失敗しました"})
+			return
+		}
+		var suspicious []referralDocument
+		if err := cursor.All(ctx, &suspicious); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "不正疑いの紹介の取得に失敗しました"})
+			return
+		}
+		flagged := make([]referralResponse, 0, len(suspicious))
+		for _, doc := range suspicious {
+			flagged = append(flagged, referralToResponse(doc, true))
+		}
+
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"totals":     totals,
+			"referrers":  referrers,
+			"suspicious": flagged,
+		})
+	}
+}
+
+func isReferralRejection(err error) bool {
+	for _, target := range []error{errReferralCodeUnknown, errReferralSelf, errReferralNotFirst, errReferralCapReached, errReferralAlreadyRedeem} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
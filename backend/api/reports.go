@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	reportStatusOpen        = "open"
+	reportStatusDismissed   = "dismissed"
+	reportStatusEdited      = "edited"
+	reportStatusUnpublished = "unpublished"
+
+	reportActionDismiss   = "dismiss"
+	reportActionEdit      = "edit"
+	reportActionUnpublish = "unpublish"
+
+	reviewStatusUnpublished = "unpublished"
+)
+
+var reportReasons = []feedbackReason{
+	{Code: "false_info", Label: "事実と異なる内容"},
+	{Code: "personal_info", Label: "個人情報が含まれている"},
+	{Code: "harassment", Label: "誹謗中傷・嫌がらせ"},
+	{Code: "spam", Label: "スパム・宣伝"},
+}
+
+var reportActionStatuses = map[string]string{
+	reportActionDismiss:   reportStatusDismissed,
+	reportActionEdit:      reportStatusEdited,
+	reportActionUnpublish: reportStatusUnpublished,
+}
+
+type reviewReportDocument struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	ReviewID       primitive.ObjectID `bson:"reviewId"`
+	ReporterID     string             `bson:"reporterId"`
+	Reason         string             `bson:"reason"`
+	Message        string             `bson:"message,omitempty"`
+	Status         string             `bson:"status"`
+	ResolvedBy     string             `bson:"resolvedBy,omitempty"`
+	ResolutionNote string             `bson:"resolutionNote,omitempty"`
+	ResolvedAt     *time.Time         `bson:"resolvedAt,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt"`
+}
+
+type createReportRequest struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+type reviewReportResponse struct {
+	ID             string     `json:"id"`
+	ReviewID       string     `json:"reviewId"`
+	ReporterID     string     `json:"reporterId,omitempty"`
+	Reason         string     `json:"reason"`
+	ReasonLabel    string     `json:"reasonLabel"`
+	Message        string     `json:"message,omitempty"`
+	Status         string     `json:"status"`
+	ResolvedBy     string     `json:"resolvedBy,omitempty"`
+	ResolutionNote string     `json:"resolutionNote,omitempty"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type reportQueueItem struct {
+	Review       adminReviewResponse    `json:"review"`
+	ReportCount  int                    `json:"reportCount"`
+	ReasonCounts map[string]int         `json:"reasonCounts"`
+	LatestAt     time.Time              `json:"latestAt"`
+	Reports      []reviewReportResponse `json:"reports"`
+}
+
+type resolveReportRequest struct {
+	Action     string  `json:"action"`
+	ResolvedBy string  `json:"resolvedBy"`
+	Note       string  `json:"note"`
+	Comment    *string `json:"comment"`
+}
+
+func reportReasonLabel(code string) (string, bool) {
+	for _, reason := range reportReasons {
+		if reason.Code == code {
+			return reason.Label, true
+		}
+	}
+	return "", false
+}
+
+func reviewReportToResponse(doc reviewReportDocument, includeReporter bool) reviewReportResponse {
+	label, _ := reportReasonLabel(doc.Reason)
+	response := reviewReportResponse{
+		ID:             doc.ID.Hex(),
+		ReviewID:       doc.ReviewID.Hex(),
+		Reason:         doc.Reason,
+		ReasonLabel:    label,
+		Message:        doc.Message,
+		Status:         doc.Status,
+		ResolvedBy:     doc.ResolvedBy,
+		ResolutionNote: doc.ResolutionNote,
+		ResolvedAt:     doc.ResolvedAt,
+		CreatedAt:      doc.CreatedAt,
+	}
+	if includeReporter {
+		response.ReporterID = doc.ReporterID
+	}
+	return response
+}
+
+func (s *server) reviewReportCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "レビューIDの形式が不正です"})
+			return
+		}
+
+		var req createReportRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		reason := strings.TrimSpace(req.Reason)
+		if _, ok := reportReasonLabel(reason); !ok {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "通報理由が不正です"})
+			return
+		}
+		message := strings.TrimSpace(req.Message)
+		if len([]rune(message)) > 1000 {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "通報内容は1000文字以内で入力してください"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var review reviewDocument
+		if err := s.reviews.FindOne(ctx, publicReviewFilter(bson.M{"_id": objectID})).Decode(&review); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+				return
+			}
+			s.logger.Printf("通報対象レビューの取得に失敗 id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+			return
+		}
+		if review.ReviewerID == user.ID {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "自分のレビューは通報できません"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		recent, err := s.reviewReports.CountDocuments(ctx, bson.M{
+			"reporterId": user.ID,
+			"createdAt":  bson.M{"$gte": now.Add(-s.reportRateWindow)},
+		})
+		if err != nil {
+			s.logger.Printf("通報回数の確認に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報の受付に失敗しました"})
+			return
+		}
+		if recent >= int64(s.reportRateLimit) {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(s.reportRateWindow.Seconds())))
+			s.writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "短時間に多くの通報が行われました。しばらくしてから再度お試しください"})
+			return
+		}
+		if err := s.reviewReports.FindOne(ctx, bson.M{"reviewId": objectID, "reporterId": user.ID, "status": reportStatusOpen}).Err(); err == nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューは既に通報済みです"})
+			return
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("通報の重複確認に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報の受付に失敗しました"})
+			return
+		}
+
+		doc := reviewReportDocument{
+			ID:         primitive.NewObjectID(),
+			ReviewID:   objectID,
+			ReporterID: user.ID,
+			Reason:     reason,
+			Message:    message,
+			Status:     reportStatusOpen,
+			CreatedAt:  now,
+		}
+		if _, err := s.reviewReports.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("通報の保存に失敗 reviewId=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報の受付に失敗しました"})
+			return
+		}
+
+		s.logger.Printf("review report created id=%s reviewId=%s reason=%s", doc.ID.Hex(), objectID.Hex(), reason)
+		s.writeJSON(w, http.StatusCreated, reviewReportToResponse(doc, false))
+	}
+}
+
+func (s *server) reportReasonListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": reportReasons})
+	}
+}
+
+func (s *server) adminReportQueueHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := strings.TrimSpace(r.URL.Query().Get("status"))
+		if status == "" {
+			status = reportStatusOpen
+		}
+		filter := bson.M{}
+		if status != "all" {
+			filter["status"] = status
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		cursor, err := s.reviewReports.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+		if err != nil {
+			s.logger.Printf("admin report queue failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報一覧の取得に失敗しました"})
+			return
+		}
+		var reports []reviewReportDocument
+		if err := cursor.All(ctx, &reports); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報一覧の取得に失敗しました"})
+			return
+		}
+
+		grouped := make(map[primitive.ObjectID][]reviewReportDocument)
+		reviewIDs := make([]primitive.ObjectID, 0)
+		for _, report := range reports {
+			if _, ok := grouped[report.ReviewID]; !ok {
+				reviewIDs = append(reviewIDs, report.ReviewID)
+			}
+			grouped[report.ReviewID] = append(grouped[report.ReviewID], report)
+		}
+
+		reviewMap := make(map[primitive.ObjectID]reviewDocument, len(reviewIDs))
+		storeIDs := make([]primitive.ObjectID, 0, len(reviewIDs))
+		if len(reviewIDs) > 0 {
+			reviewCursor, err := s.reviews.Find(ctx, bson.M{"_id": bson.M{"$in": reviewIDs}})
+			if err != nil {
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報対象レビューの取得に失敗しました"})
+				return
+			}
+			var reviews []reviewDocument
+			if err := reviewCursor.All(ctx, &reviews); err != nil {
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報対象レビューの取得に失敗しました"})
+				return
+			}
+			for _, review := range reviews {
+				reviewMap[review.ID] = review
+				storeIDs = append(storeIDs, review.StoreID)
+			}
+		}
+		storeMap, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		items := make([]reportQueueItem, 0, len(reviewIDs))
+		for _, reviewID := range reviewIDs {
+			review, ok := reviewMap[reviewID]
+			if !ok {
+				continue
+			}
+			group := grouped[reviewID]
+			item := reportQueueItem{
+				Review:       buildAdminReviewResponse(review, storeMap[review.StoreID]),
+				ReportCount:  len(group),
+				ReasonCounts: make(map[string]int),
+				Reports:      make([]reviewReportResponse, 0, len(group)),
+			}
+			for _, report := range group {
+				item.ReasonCounts[report.Reason]++
+				if report.CreatedAt.After(item.LatestAt) {
+					item.LatestAt = report.CreatedAt
+				}
+				item.Reports = append(item.Reports, reviewReportToResponse(report, true))
+			}
+			items = append(items, item)
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].ReportCount != items[j].ReportCount {
+				return items[i].ReportCount > items[j].ReportCount
+			}
+			return items[i].LatestAt.After(items[j].LatestAt)
+		})
+
+		s.logger.Printf("admin report queue: status=%q reviews=%d reports=%d", status, len(items), len(reports))
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminReportResolveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req resolveReportRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		action := strings.TrimSpace(req.Action)
+		reportStatus, ok := reportActionStatuses[action]
+		if !ok {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "actionはdismiss, edit, unpublishのいずれかを指定してください"})
+			return
+		}
+		resolvedBy := strings.TrimSpace(req.ResolvedBy)
+		note := strings.TrimSpace(req.Note)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+
+		updated := existing
+		switch action {
+		case reportActionEdit:
+			comment := ""
+			if req.Comment != nil {
+				comment = strings.TrimSpace(*req.Comment)
+			}
+			if comment == "" {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "editでは修正後の感想(comment)を指定してください"})
+				return
+			}
+			if len([]rune(comment)) > 2000 {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "感想は2000文字以内で入力してください"})
+				return
+			}
+			edited, err := s.updateReviewContentAsAdmin(ctx, existing, bson.M{"comment": comment}, resolvedBy, "report_edit", 0)
+			if errors.Is(err, errRevisionConflict) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				s.logger.Printf("通報対応のレビュー編集に失敗 id=%s err=%v", existing.ID.Hex(), err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの更新に失敗しました"})
+				return
+			}
+			updated = edited
+		case reportActionUnpublish:
+			if existing.Status != "approved" {
+				break
+			}
+			statusNote := "通報により非公開"
+			if note != "" {
+				statusNote = note
+			}
+			unpublished, err := s.unpublishReview(ctx, existing, resolvedBy, statusNote)
+			if err != nil {
+				s.logger.Printf("通報対応のレビュー非公開化に失敗 id=%s err=%v", existing.ID.Hex(), err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの非公開化に失敗しました"})
+				return
+			}
+			updated = unpublished
+		}
+
+		now := time.Now().In(s.location)
+		result, err := s.reviewReports.UpdateMany(ctx, bson.M{"reviewId": existing.ID, "status": reportStatusOpen}, bson.M{"$set": bson.M{
+			"status":         reportStatus,
+			"resolvedBy":     resolvedBy,
+			"resolutionNote": note,
+			"resolvedAt":     now,
+		}})
+		if err != nil {
+			s.logger.Printf("通報の解決に失敗 reviewId=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "通報の更新に失敗しました"})
+			return
+		}
+
+		if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+			s.logger.Printf("通報対応後の店舗統計更新に失敗 reviewId=%s err=%v", updated.ID.Hex(), err)
+		}
+		store, err := s.getStoreByID(ctx, updated.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		s.handleReviewStatusTransition(ctx, existing, updated, store)
+
+		s.logger.Printf("admin report resolve reviewId=%s action=%s resolved=%d", existing.ID.Hex(), action, result.ModifiedCount)
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"review":   buildAdminReviewResponse(updated, store),
+			"resolved": result.ModifiedCount,
+		})
+	}
+}
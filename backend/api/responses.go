@@ -0,0 +1,615 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	responseStatusPending  = "pending"
+	responseStatusApproved = "approved"
+	responseStatusRejected = "rejected"
+
+	maxResponseBodyRunes = 1000
+	defaultResponseTitle = "店舗担当者"
+)
+
+type storeRepresentativeDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    string             `bson:"userId"`
+	StoreID   primitive.ObjectID `bson:"storeId"`
+	Title     string             `bson:"title,omitempty"`
+	GrantedBy string             `bson:"grantedBy,omitempty"`
+	GrantedAt time.Time          `bson:"grantedAt"`
+	RevokedBy string             `bson:"revokedBy,omitempty"`
+	RevokedAt *time.Time         `bson:"revokedAt,omitempty"`
+}
+
+type storeRepresentativeResponse struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	StoreID    string     `json:"storeId"`
+	StoreName  string     `json:"storeName,omitempty"`
+	BranchName string     `json:"branchName,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	GrantedBy  string     `json:"grantedBy,omitempty"`
+	GrantedAt  time.Time  `json:"grantedAt"`
+	RevokedBy  string     `json:"revokedBy,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type reviewResponseDocument struct {
+	ID             primitive.ObjectID       `bson:"_id"`
+	ReviewID       primitive.ObjectID       `bson:"reviewId"`
+	StoreID        primitive.ObjectID       `bson:"storeId"`
+	ResponderID    string                   `bson:"responderId"`
+	ResponderTitle string                   `bson:"responderTitle,omitempty"`
+	Body           string                   `bson:"body"`
+	Status         string                   `bson:"status"`
+	Findings       []contentFindingDocument `bson:"findings,omitempty"`
+	ModeratedBy    string                   `bson:"moderatedBy,omitempty"`
+	ModeratedAt    *time.Time               `bson:"moderatedAt,omitempty"`
+	ModerationNote string                   `bson:"moderationNote,omitempty"`
+	PublishedAt    *time.Time               `bson:"publishedAt,omitempty"`
+	CreatedAt      time.Time                `bson:"createdAt"`
+	UpdatedAt      time.Time                `bson:"updatedAt"`
+}
+
+type reviewResponseAdminResponse struct {
+	ID             string           `json:"id"`
+	ReviewID       string           `json:"reviewId"`
+	StoreID        string           `json:"storeId"`
+	StoreName      string           `json:"storeName,omitempty"`
+	ResponderID    string           `json:"responderId"`
+	ResponderTitle string           `json:"responderTitle,omitempty"`
+	Body           string           `json:"body"`
+	Status         string           `json:"status"`
+	Findings       []contentFinding `json:"findings,omitempty"`
+	ModeratedBy    string           `json:"moderatedBy,omitempty"`
+	ModeratedAt    *time.Time       `json:"moderatedAt,omitempty"`
+	ModerationNote string           `json:"moderationNote,omitempty"`
+	PublishedAt    *time.Time       `json:"publishedAt,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+type officialResponse struct {
+	Body           string    `json:"body"`
+	ResponderTitle string    `json:"responderTitle"`
+	PublishedAt    time.Time `json:"publishedAt"`
+}
+
+type grantRepresentativeRequest struct {
+	UserID    string `json:"userId"`
+	Title     string `json:"title"`
+	GrantedBy string `json:"grantedBy"`
+}
+
+type submitReviewResponseRequest struct {
+	Body string `json:"body"`
+}
+
+type moderateReviewResponseRequest struct {
+	Action      string `json:"action"`
+	ModeratedBy string `json:"moderatedBy"`
+	Note        string `json:"note"`
+}
+
+func (s *server) ensureReviewResponseIndexes(ctx context.Context) error {
+	if _, err := s.storeReps.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "storeId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	_, err := s.reviewResponses.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "reviewId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func storeRepresentativeToResponse(doc storeRepresentativeDocument, store storeDocument) storeRepresentativeResponse {
+	return storeRepresentativeResponse{
+		ID:         doc.ID.Hex(),
+		UserID:     doc.UserID,
+		StoreID:    doc.StoreID.Hex(),
+		StoreName:  store.Name,
+		BranchName: strings.TrimSpace(store.BranchName),
+		Title:      doc.Title,
+		GrantedBy:  doc.GrantedBy,
+		GrantedAt:  doc.GrantedAt,
+		RevokedBy:  doc.RevokedBy,
+		RevokedAt:  doc.RevokedAt,
+	}
+}
+
+func reviewResponseToAdmin(doc reviewResponseDocument, store storeDocument) reviewResponseAdminResponse {
+	return reviewResponseAdminResponse{
+		ID:             doc.ID.Hex(),
+		ReviewID:       doc.ReviewID.Hex(),
+		StoreID:        doc.StoreID.Hex(),
+		StoreName:      storeLabel(store),
+		ResponderID:    doc.ResponderID,
+		ResponderTitle: doc.ResponderTitle,
+		Body:           doc.Body,
+		Status:         doc.Status,
+		Findings:       buildContentFindings(doc.Findings),
+		ModeratedBy:    doc.ModeratedBy,
+		ModeratedAt:    doc.ModeratedAt,
+		ModerationNote: doc.ModerationNote,
+		PublishedAt:    doc.PublishedAt,
+		CreatedAt:      doc.CreatedAt,
+		UpdatedAt:      doc.UpdatedAt,
+	}
+}
+
+func (s *server) activeRepresentative(ctx context.Context, userID string, storeID primitive.ObjectID) (storeRepresentativeDocument, bool, error) {
+	var rep storeRepresentativeDocument
+	err := s.storeReps.FindOne(ctx, bson.M{
+		"userId":    userID,
+		"storeId":   storeID,
+		"revokedAt": bson.M{"$exists": false},
+	}).Decode(&rep)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rep, false, nil
+	}
+	if err != nil {
+		return rep, false, err
+	}
+	return rep, true, nil
+}
+
+func (s *server) loadOfficialResponse(ctx context.Context, reviewID primitive.ObjectID) *officialResponse {
+	var doc reviewResponseDocument
+	if err := s.reviewResponses.FindOne(ctx, bson.M{"reviewId": reviewID, "status": responseStatusApproved}).Decode(&doc); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("公式返信の取得に失敗 reviewId=%s err=%v", reviewID.Hex(), err)
+		}
+		return nil
+	}
+	response := &officialResponse{
+		Body:           doc.Body,
+		ResponderTitle: doc.ResponderTitle,
+	}
+	if s.contentMaskMode == contentMaskModeMask {
+		response.Body = maskComment(doc.Body, doc.Findings)
+	}
+	if doc.PublishedAt != nil {
+		response.PublishedAt = *doc.PublishedAt
+	}
+	return response
+}
+
+func (s *server) loadRepresentatives(ctx context.Context, filter bson.M) ([]storeRepresentativeResponse, error) {
+	cursor, err := s.storeReps.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "grantedAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []storeRepresentativeDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	storeIDs := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		storeIDs = append(storeIDs, doc.StoreID)
+	}
+	stores, err := s.loadStoresMap(ctx, storeIDs)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]storeRepresentativeResponse, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, storeRepresentativeToResponse(doc, stores[doc.StoreID]))
+	}
+	return items, nil
+}
+
+func (s *server) adminStoreRepresentativeListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := bson.M{}
+		if raw := strings.TrimSpace(query.Get("storeId")); raw != "" {
+			storeID, err := primitive.ObjectIDFromHex(raw)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "storeIdの形式が不正です"})
+				return
+			}
+			filter["storeId"] = storeID
+		}
+		if userID := strings.TrimSpace(query.Get("userId")); userID != "" {
+			filter["userId"] = userID
+		}
+		if query.Get("includeRevoked") != "true" {
+			filter["revokedAt"] = bson.M{"$exists": false}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		items, err := s.loadRepresentatives(ctx, filter)
+		if err != nil {
+			s.logger.Printf("admin store representative list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗担当者一覧の取得に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminStoreRepresentativeGrantHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		storeID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "店舗IDの形式が不正です"})
+			return
+		}
+		var req grantRepresentativeRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		userID := strings.TrimSpace(req.UserID)
+		if userID == "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "userIdを指定してください"})
+			return
+		}
+		title := strings.TrimSpace(req.Title)
+		if len([]rune(title)) > 30 {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "肩書きは30文字以内で入力してください"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		store, err := s.getStoreByID(ctx, storeID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "店舗が見つかりません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		var rep storeRepresentativeDocument
+		result := s.storeReps.FindOneAndUpdate(ctx,
+			bson.M{"userId": userID, "storeId": storeID},
+			bson.M{
+				"$set": bson.M{
+					"title":     title,
+					"grantedBy": strings.TrimSpace(req.GrantedBy),
+					"grantedAt": now,
+				},
+				"$unset":       bson.M{"revokedAt": "", "revokedBy": ""},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		)
+		if err := result.Decode(&rep); err != nil {
+			s.logger.Printf("店舗担当者の登録に失敗 storeId=%s userId=%s err=%v", storeID.Hex(), userID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗担当者の登録に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin store representative granted storeId=%s userId=%s by=%q", storeID.Hex(), userID, rep.GrantedBy)
+		s.writeJSON(w, http.StatusCreated, storeRepresentativeToResponse(rep, store))
+	}
+}
+
+func (s *server) adminStoreRepresentativeRevokeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "IDの形式が不正です"})
+			return
+		}
+		revokedBy := strings.TrimSpace(r.URL.Query().Get("revokedBy"))
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		result, err := s.storeReps.UpdateOne(ctx,
+			bson.M{"_id": objectID, "revokedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revokedAt": now, "revokedBy": revokedBy}},
+		)
+		if err != nil {
+			s.logger.Printf("店舗担当者の解除に失敗 id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗担当者の解除に失敗しました"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "有効な店舗担当者が見つかりません"})
+			return
+		}
+		s.logger.Printf("admin store representative revoked id=%s by=%q", objectID.Hex(), revokedBy)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) myStoreRepresentationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		items, err := s.loadRepresentatives(ctx, bson.M{"userId": user.ID, "revokedAt": bson.M{"$exists": false}})
+		if err != nil {
+			s.logger.Printf("担当店舗の取得に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "担当店舗の取得に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) reviewResponseSubmitHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authenticatedUserFromContext(r.Context())
+		if !ok {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "認証情報を取得できませんでした"})
+			return
+		}
+		reviewID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "レビューIDの形式が不正です"})
+			return
+		}
+		var req submitReviewResponseRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		body := strings.TrimSpace(req.Body)
+		if body == "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "返信内容を入力してください"})
+			return
+		}
+		if len([]rune(body)) > maxResponseBodyRunes {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("返信内容は%d文字以内で入力してください", maxResponseBodyRunes)})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var review reviewDocument
+		if err := s.reviews.FindOne(ctx, publicReviewFilter(bson.M{"_id": reviewID})).Decode(&review); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+				return
+			}
+			s.logger.Printf("返信対象レビューの取得に失敗 id=%s err=%v", reviewID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+			return
+		}
+		rep, ok, err := s.activeRepresentative(ctx, user.ID, review.StoreID)
+		if err != nil {
+			s.logger.Printf("店舗担当者の確認に失敗 userId=%s err=%v", user.ID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗担当者の確認に失敗しました"})
+			return
+		}
+		if !ok {
+			s.writeJSON(w, http.StatusForbidden, map[string]string{"error": "この店舗の担当者として登録されていません"})
+			return
+		}
+
+		var existing reviewResponseDocument
+		err = s.reviewResponses.FindOne(ctx, bson.M{"reviewId": review.ID}).Decode(&existing)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("公式返信の取得に失敗 reviewId=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "公式返信の取得に失敗しました"})
+			return
+		}
+		if err == nil && existing.Status == responseStatusApproved {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューには既に公式返信が公開されています"})
+			return
+		}
+
+		title := rep.Title
+		if title == "" {
+			title = defaultResponseTitle
+		}
+		now := time.Now().In(s.location)
+		var saved reviewResponseDocument
+		result := s.reviewResponses.FindOneAndUpdate(ctx,
+			bson.M{"reviewId": review.ID, "status": bson.M{"$ne": responseStatusApproved}},
+			bson.M{
+				"$set": bson.M{
+					"storeId":        review.StoreID,
+					"responderId":    user.ID,
+					"responderTitle": title,
+					"body":           body,
+					"status":         responseStatusPending,
+					"findings":       s.scanReviewContent(ctx, body),
+					"updatedAt":      now,
+				},
+				"$unset":       bson.M{"moderatedBy": "", "moderatedAt": "", "moderationNote": ""},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "createdAt": now},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		)
+		if err := result.Decode(&saved); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューには既に公式返信が公開されています"})
+				return
+			}
+			s.logger.Printf("公式返信の保存に失敗 reviewId=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "公式返信の保存に失敗しました"})
+			return
+		}
+
+		s.logger.Printf("official response submitted reviewId=%s storeId=%s userId=%s findings=%d", review.ID.Hex(), review.StoreID.Hex(), user.ID, len(saved.Findings))
+		s.writeJSON(w, http.StatusAccepted, map[string]any{
+			"id":     saved.ID.Hex(),
+			"status": saved.Status,
+		})
+	}
+}
+
+func (s *server) adminReviewResponseQueueHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := strings.TrimSpace(r.URL.Query().Get("status"))
+		if status == "" {
+			status = responseStatusPending
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.reviewResponses.Find(ctx, bson.M{"status": status}, options.Find().SetSort(bson.D{{Key: "updatedAt", Value: 1}}).SetLimit(200))
+		if err != nil {
+			s.logger.Printf("admin review response queue failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "公式返信一覧の取得に失敗しました"})
+			return
+		}
+		var docs []reviewResponseDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "公式返信一覧の取得に失敗しました"})
+			return
+		}
+		storeIDs := make([]primitive.ObjectID, 0, len(docs))
+		for _, doc := range docs {
+			storeIDs = append(storeIDs, doc.StoreID)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		items := make([]reviewResponseAdminResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, reviewResponseToAdmin(doc, stores[doc.StoreID]))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminReviewResponseModerateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "IDの形式が不正です"})
+			return
+		}
+		var req moderateReviewResponseRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		var status string
+		switch strings.TrimSpace(req.Action) {
+		case "approve":
+			status = responseStatusApproved
+		case "reject":
+			status = responseStatusRejected
+		default:
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "actionはapproveまたはrejectを指定してください"})
+			return
+		}
+		moderator := strings.TrimSpace(req.ModeratedBy)
+		note := strings.TrimSpace(req.Note)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now().In(s.location)
+		update := bson.M{
+			"status":         status,
+			"moderatedBy":    moderator,
+			"moderatedAt":    now,
+			"moderationNote": note,
+			"updatedAt":      now,
+		}
+		if status == responseStatusApproved {
+			update["publishedAt"] = now
+		}
+		var updated reviewResponseDocument
+		result := s.reviewResponses.FindOneAndUpdate(ctx,
+			bson.M{"_id": objectID, "status": responseStatusPending},
+			bson.M{"$set": update},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		)
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "審査待ちの公式返信が見つかりません"})
+				return
+			}
+			s.logger.Printf("公式返信の審査に失敗 id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "公式返信の審査に失敗しました"})
+			return
+		}
+		s.recordReviewAudit(ctx, updated.ReviewID, "official_response_"+status, moderator, auditActorAdmin, bson.M{"responseId": updated.ID.Hex(), "responderId": updated.ResponderID})
+
+		store, err := s.getStoreByID(ctx, updated.StoreID)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+		if status == responseStatusApproved {
+			var review reviewDocument
+			if err := s.reviews.FindOne(ctx, bson.M{"_id": updated.ReviewID}).Decode(&review); err != nil {
+				s.logger.Printf("返信通知対象レビューの取得に失敗 reviewId=%s err=%v", updated.ReviewID.Hex(), err)
+			} else {
+				go s.notifyOfficialResponse(context.Background(), review, store, updated)
+			}
+		} else {
+			go s.notifyReviewer(context.Background(), updated.ResponderID, notification{
+				Subject:        "公式返信が公開されませんでした",
+				Text:           buildResponseRejectedMessage(store, note),
+				IdempotencyKey: fmt.Sprintf("review-response-rejected:%s:%d", updated.ID.Hex(), updated.UpdatedAt.Unix()),
+			})
+		}
+		s.logger.Printf("admin official response %s id=%s reviewId=%s by=%q", status, updated.ID.Hex(), updated.ReviewID.Hex(), moderator)
+		s.writeJSON(w, http.StatusOK, reviewResponseToAdmin(updated, store))
+	}
+}
+
+func (s *server) notifyOfficialResponse(ctx context.Context, review reviewDocument, store storeDocument, response reviewResponseDocument) {
+	lines := []string{
+		"あなたのアンケートに店舗から公式返信が届きました。",
+		"",
+		"**店舗名**",
+		"> " + storeLabel(store),
+		"",
+		"**" + response.ResponderTitle + "からの返信**",
+		"> " + strings.ReplaceAll(response.Body, "\n", "\n> "),
+		"",
+		"レビューページから返信を確認できます。",
+	}
+	s.notifyReviewer(ctx, review.ReviewerID, notification{
+		Subject:        "店舗から公式返信が届きました",
+		Text:           strings.Join(lines, "\n"),
+		IdempotencyKey: "review-response:" + response.ID.Hex(),
+	})
+}
+
+func buildResponseRejectedMessage(store storeDocument, note string) string {
+	lines := []string{
+		storeLabel(store) + "のレビューへの公式返信は、審査の結果公開されませんでした。",
+	}
+	if note != "" {
+		lines = append(lines, "", "**理由**", "> "+note)
+	}
+	lines = append(lines, "", "内容を修正して再度投稿いただけます。")
+	return strings.Join(lines, "\n")
+}
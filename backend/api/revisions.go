@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errRevisionConflict = errors.New("他の編集と競合しました。最新の内容を確認してから再度編集してください")
+
+type reviewContentSnapshot struct {
+	StoreID        primitive.ObjectID `bson:"storeId"`
+	IndustryCode   string             `bson:"industryCode"`
+	Period         string             `bson:"period,omitempty"`
+	Age            *int               `bson:"age,omitempty"`
+	SpecScore      *int               `bson:"specScore,omitempty"`
+	WaitTimeHours  *int               `bson:"waitTimeHours,omitempty"`
+	AverageEarning *int               `bson:"averageEarning,omitempty"`
+	Rating         float64            `bson:"rating"`
+	Comment        string             `bson:"comment"`
+}
+
+type reviewRevisionDocument struct {
+	ID           primitive.ObjectID    `bson:"_id"`
+	ReviewID     primitive.ObjectID    `bson:"reviewId"`
+	Revision     int                   `bson:"revision"`
+	Content      reviewContentSnapshot `bson:"content"`
+	EditedBy     string                `bson:"editedBy,omitempty"`
+	EditorRole   string                `bson:"editorRole"`
+	Reason       string                `bson:"reason,omitempty"`
+	RestoredFrom int                   `bson:"restoredFrom,omitempty"`
+	CreatedAt    time.Time             `bson:"createdAt"`
+}
+
+type reviewRevisionContentResponse struct {
+	StoreID        string  `json:"storeId"`
+	StoreName      string  `json:"storeName"`
+	BranchName     string  `json:"branchName,omitempty"`
+	Prefecture     string  `json:"prefecture,omitempty"`
+	Category       string  `json:"category"`
+	VisitedAt      string  `json:"visitedAt"`
+	Age            *int    `json:"age,omitempty"`
+	SpecScore      *int    `json:"specScore,omitempty"`
+	WaitTimeHours  *int    `json:"waitTimeHours,omitempty"`
+	AverageEarning *int    `json:"averageEarning,omitempty"`
+	Rating         float64 `json:"rating"`
+	Comment        string  `json:"comment"`
+}
+
+type reviewRevisionChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type reviewRevisionResponse struct {
+	Revision     int                           `json:"revision"`
+	EditedBy     string                        `json:"editedBy,omitempty"`
+	EditorRole   string                        `json:"editorRole"`
+	Reason       string                        `json:"reason,omitempty"`
+	RestoredFrom int                           `json:"restoredFrom,omitempty"`
+	Current      bool                          `json:"current"`
+	Published    bool                          `json:"published"`
+	Content      reviewRevisionContentResponse `json:"content"`
+	Changes      []reviewRevisionChange        `json:"changes"`
+	CreatedAt    time.Time                     `json:"createdAt"`
+}
+
+type restoreReviewRevisionRequest struct {
+	EditedBy string `json:"editedBy"`
+}
+
+func currentRevision(review reviewDocument) int {
+	if review.Revision < 1 {
+		return 1
+	}
+	return review.Revision
+}
+
+func revisionMatch(review reviewDocument) any {
+	if review.Revision <= 1 {
+		return bson.M{"$in": bson.A{nil, 0, 1}}
+	}
+	return review.Revision
+}
+
+func (s *server) ensureReviewRevisionIndex(ctx context.Context) error {
+	_, err := s.reviewRevisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "reviewId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func snapshotReviewContent(review reviewDocument) reviewContentSnapshot {
+	return reviewContentSnapshot{
+		StoreID:        review.StoreID,
+		IndustryCode:   review.IndustryCode,
+		Period:         review.Period,
+		Age:            review.Age,
+		SpecScore:      review.SpecScore,
+		WaitTimeHours:  review.WaitTimeHours,
+		AverageEarning: review.AverageEarning,
+		Rating:         review.Rating,
+		Comment:        review.Comment,
+	}
+}
+
+func (s *server) ensureReviewRevision(ctx context.Context, review reviewDocument, editedBy, editorRole, reason string, restoredFrom int) error {
+	revision := currentRevision(review)
+	doc := reviewRevisionDocument{
+		ID:           primitive.NewObjectID(),
+		ReviewID:     review.ID,
+		Revision:     revision,
+		Content:      snapshotReviewContent(review),
+		EditedBy:     editedBy,
+		EditorRole:   editorRole,
+		Reason:       reason,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    review.UpdatedAt,
+	}
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now().In(s.location)
+	}
+
+	_, err := s.reviewRevisions.UpdateOne(ctx,
+		bson.M{"reviewId": review.ID, "revision": revision},
+		bson.M{"$setOnInsert": doc},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *server) updateReviewContentAsAdmin(ctx context.Context, existing reviewDocument, set bson.M, editedBy, reason string, restoredFrom int) (reviewDocument, error) {
+	if err := s.ensureReviewRevision(ctx, existing, existing.ReviewerID, auditActorReviewer, "original", 0); err != nil {
+		return reviewDocument{}, err
+	}
+
+	revision := currentRevision(existing) + 1
+	set["revision"] = revision
+	if strings.TrimSpace(existing.Status) == "approved" {
+		set["publishedRevision"] = revision
+	}
+	set["updatedAt"] = time.Now().In(s.location)
+	if comment, ok := set["comment"].(string); ok {
+		set["contentFindings"] = s.scanReviewContent(ctx, comment)
+	}
+
+	candidate := existing
+	if storeID, ok := set["storeId"].(primitive.ObjectID); ok {
+		candidate.StoreID = storeID
+	}
+	if category, ok := set["industryCode"].(string); ok {
+		candidate.IndustryCode = category
+	}
+	campaign, rematched := existing.Campaign, false
+	if candidate.StoreID != existing.StoreID || candidate.IndustryCode != existing.IndustryCode {
+		if store, err := s.getStoreByID(ctx, candidate.StoreID); err != nil {
+			s.logger.Printf("キャンペーン再判定用の店舗取得に失敗 id=%s err=%v", existing.ID.Hex(), err)
+		} else {
+			campaign, rematched = s.rematchReviewCampaign(ctx, existing, candidate, store)
+		}
+	}
+
+	var updated reviewDocument
+	result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": existing.ID, "revision": revisionMatch(existing)}, withCampaignUpdate(set, campaign, rematched), options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&updated); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return reviewDocument{}, errRevisionConflict
+		}
+		return reviewDocument{}, err
+	}
+
+	if err := s.ensureReviewRevision(ctx, updated, editedBy, auditActorAdmin, reason, restoredFrom); err != nil {
+		s.logger.Printf("リビジョンの保存に失敗 id=%s revision=%d err=%v", updated.ID.Hex(), updated.Revision, err)
+	}
+	s.indexReviewComment(ctx, updated)
+	s.rescoreReviewAnomaly(ctx, &updated)
+	s.recordReviewAudit(ctx, updated.ID, "admin_"+reason, editedBy, auditActorAdmin, bson.M{
+		"revision":        updated.Revision,
+		"previousStoreId": existing.StoreID,
+		"storeId":         updated.StoreID,
+	})
+	return updated, nil
+}
+
+func (s *server) loadReviewRevisions(ctx context.Context, review reviewDocument) ([]reviewRevisionDocument, error) {
+	cursor, err := s.reviewRevisions.Find(ctx, bson.M{"reviewId": review.ID}, options.Find().SetSort(bson.D{{Key: "revision", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []reviewRevisionDocument
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	current := currentRevision(review)
+	if len(revisions) == 0 || revisions[len(revisions)-1].Revision < current {
+		revisions = append(revisions, reviewRevisionDocument{
+			ReviewID:   review.ID,
+			Revision:   current,
+			Content:    snapshotReviewContent(review),
+			EditedBy:   review.ReviewerID,
+			EditorRole: auditActorReviewer,
+			CreatedAt:  review.UpdatedAt,
+		})
+	}
+	return revisions, nil
+}
+
+func buildRevisionContent(content reviewContentSnapshot, store storeDocument) reviewRevisionContentResponse {
+	return reviewRevisionContentResponse{
+		StoreID:        objectIDHex(content.StoreID),
+		StoreName:      store.Name,
+		BranchName:     store.BranchName,
+		Prefecture:     store.Prefecture,
+		Category:       canonicalIndustryCode(content.IndustryCode),
+		VisitedAt:      content.Period,
+		Age:            content.Age,
+		SpecScore:      content.SpecScore,
+		WaitTimeHours:  content.WaitTimeHours,
+		AverageEarning: content.AverageEarning,
+		Rating:         content.Rating,
+		Comment:        content.Comment,
+	}
+}
+
+func storeLabel(store storeDocument) string {
+	label := store.Name
+	if store.BranchName != "" {
+		label += " " + store.BranchName
+	}
+	if store.Prefecture != "" {
+		label += "（" + store.Prefecture + "）"
+	}
+	return label
+}
+
+func intPtrAny(value *int) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func diffReviewContent(before, after reviewContentSnapshot, stores map[primitive.ObjectID]storeDocument) []reviewRevisionChange {
+	changes := []reviewRevisionChange{}
+	if before.StoreID != after.StoreID {
+		changes = append(changes, reviewRevisionChange{Field: "store", Before: storeLabel(stores[before.StoreID]), After: storeLabel(stores[after.StoreID])})
+	}
+	if canonicalIndustryCode(before.IndustryCode) != canonicalIndustryCode(after.IndustryCode) {
+		changes = append(changes, reviewRevisionChange{Field: "category", Before: before.IndustryCode, After: after.IndustryCode})
+	}
+	if before.Period != after.Period {
+		changes = append(changes, reviewRevisionChange{Field: "visitedAt", Before: before.Period, After: after.Period})
+	}
+	intFields := []struct {
+		field  string
+		before *int
+		after  *int
+	}{
+		{"age", before.Age, after.Age},
+		{"specScore", before.SpecScore, after.SpecScore},
+		{"waitTimeHours", before.WaitTimeHours, after.WaitTimeHours},
+		{"averageEarning", before.AverageEarning, after.AverageEarning},
+	}
+	for _, f := range intFields {
+		if intPtrAny(f.before) != intPtrAny(f.after) {
+			changes = append(changes, reviewRevisionChange{Field: f.field, Before: intPtrAny(f.before), After: intPtrAny(f.after)})
+		}
+	}
+	if before.Rating != after.Rating {
+		changes = append(changes, reviewRevisionChange{Field: "rating", Before: before.Rating, After: after.Rating})
+	}
+	if before.Comment != after.Comment {
+		changes = append(changes, reviewRevisionChange{Field: "comment", Before: before.Comment, After: after.Comment})
+	}
+	return changes
+}
+
+func (s *server) findAdminReview(ctx context.Context, w http.ResponseWriter, r *http.Request) (reviewDocument, bool) {
+	objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "レビューIDの形式が不正です"})
+		return reviewDocument{}, false
+	}
+
+	var review reviewDocument
+	if err := s.reviews.FindOne(ctx, bson.M{"_id": objectID}).Decode(&review); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビューが見つかりません"})
+			return reviewDocument{}, false
+		}
+		s.logger.Printf("レビューの取得に失敗 id=%s err=%v", objectID.Hex(), err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの取得に失敗しました"})
+		return reviewDocument{}, false
+	}
+	return review, true
+}
+
+func (s *server) adminReviewRevisionListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		review, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+
+		revisions, err := s.loadReviewRevisions(ctx, review)
+		if err != nil {
+			s.logger.Printf("リビジョン一覧の取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "リビジョンの取得に失敗しました"})
+			return
+		}
+
+		storeIDSet := make(map[primitive.ObjectID]struct{})
+		for _, revision := range revisions {
+			storeIDSet[revision.Content.StoreID] = struct{}{}
+		}
+		storeIDs := make([]primitive.ObjectID, 0, len(storeIDSet))
+		for id := range storeIDSet {
+			storeIDs = append(storeIDs, id)
+		}
+		stores, err := s.loadStoresMap(ctx, storeIDs)
+		if err != nil {
+			s.logger.Printf("リビジョン用店舗の取得に失敗 id=%s err=%v", review.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		current := currentRevision(review)
+		items := make([]reviewRevisionResponse, 0, len(revisions))
+		for i, revision := range revisions {
+			changes := []reviewRevisionChange{}
+			if i > 0 {
+				changes = diffReviewContent(revisions[i-1].Content, revision.Content, stores)
+			}
+			items = append(items, reviewRevisionResponse{
+				Revision:     revision.Revision,
+				EditedBy:     revision.EditedBy,
+				EditorRole:   revision.EditorRole,
+				Reason:       revision.Reason,
+				RestoredFrom: revision.RestoredFrom,
+				Current:      revision.Revision == current,
+				Published:    revision.Revision == review.PublishedRev,
+				Content:      buildRevisionContent(revision.Content, stores[revision.Content.StoreID]),
+				Changes:      changes,
+				CreatedAt:    revision.CreatedAt,
+			})
+		}
+
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"reviewId":          review.ID.Hex(),
+			"currentRevision":   current,
+			"publishedRevision": review.PublishedRev,
+			"items":             items,
+		})
+	}
+}
+
+func (s *server) adminReviewRevisionRestoreHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revisionNumber, err := strconv.Atoi(chi.URLParam(r, "revision"))
+		if err != nil || revisionNumber < 1 {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リビジョン番号が不正です"})
+			return
+		}
+
+		var req restoreReviewRevisionRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if revisionNumber == currentRevision(existing) {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "指定されたリビジョンは現在の内容です"})
+			return
+		}
+
+		var target reviewRevisionDocument
+		if err := s.reviewRevisions.FindOne(ctx, bson.M{"reviewId": existing.ID, "revision": revisionNumber}).Decode(&target); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "リビジョンが見つかりません"})
+				return
+			}
+			s.logger.Printf("リビジョンの取得に失敗 id=%s revision=%d err=%v", existing.ID.Hex(), revisionNumber, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "リビジョンの取得に失敗しました"})
+			return
+		}
+
+		store, err := s.getStoreByID(ctx, target.Content.StoreID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusConflict, map[string]string{"error": "リビジョンの店舗が存在しないため復元できません"})
+				return
+			}
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+			return
+		}
+
+		content := target.Content
+		updated, err := s.updateReviewContentAsAdmin(ctx, existing, bson.M{
+			"storeId":        content.StoreID,
+			"industryCode":   content.IndustryCode,
+			"period":         content.Period,
+			"age":            content.Age,
+			"specScore":      content.SpecScore,
+			"waitTimeHours":  content.WaitTimeHours,
+			"averageEarning": content.AverageEarning,
+			"rating":         content.Rating,
+			"comment":        content.Comment,
+		}, strings.TrimSpace(req.EditedBy), "restore", revisionNumber)
+		if errors.Is(err, errRevisionConflict) {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			s.logger.Printf("リビジョンの復元に失敗 id=%s revision=%d err=%v", existing.ID.Hex(), revisionNumber, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "リビジョンの復元に失敗しました"})
+			return
+		}
+
+		if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+			s.logger.Printf("リビジョン復元後の店舗統計更新に失敗 id=%s err=%v", updated.ID.Hex(), err)
+		}
+		if existing.StoreID != updated.StoreID && !existing.StoreID.IsZero() {
+			if err := s.recalculateStoreStats(ctx, existing.StoreID); err != nil {
+				s.logger.Printf("リビジョン復元後の旧店舗統計更新に失敗 id=%s err=%v", updated.ID.Hex(), err)
+			}
+		}
+
+		s.logger.Printf("admin review revision restore success id=%s from=%d revision=%d", updated.ID.Hex(), revisionNumber, updated.Revision)
+		s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
+	}
+}
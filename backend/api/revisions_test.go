@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffReviewContent(t *testing.T) {
+	storeA := primitive.NewObjectID()
+	storeB := primitive.NewObjectID()
+	stores := map[primitive.ObjectID]storeDocument{
+		storeA: {Name: "店舗A", BranchName: "新宿店", Prefecture: "東京都"},
+		storeB: {Name: "店舗B"},
+	}
+	age20, age21 := 20, 21
+	base := reviewContentSnapshot{StoreID: storeA, IndustryCode: "デリヘル", Period: "2026年9月", Age: &age20, Rating: 4, Comment: "良い"}
+
+	cases := []struct {
+		name   string
+		mutate func(*reviewContentSnapshot)
+		want   []reviewRevisionChange
+	}{
+		{"変更なし", func(*reviewContentSnapshot) {}, []reviewRevisionChange{}},
+		{"業種の表記揺れは差分にしない", func(c *reviewContentSnapshot) { c.IndustryCode = "deriheru" }, []reviewRevisionChange{}},
+		{"店舗", func(c *reviewContentSnapshot) { c.StoreID = storeB }, []reviewRevisionChange{
+			{Field: "store", Before: "店舗A 新宿店（東京都）", After: "店舗B"},
+		}},
+		{"年齢の削除", func(c *reviewContentSnapshot) { c.Age = nil }, []reviewRevisionChange{
+			{Field: "age", Before: 20, After: nil},
+		}},
+		{"複数項目", func(c *reviewContentSnapshot) {
+			c.Age = &age21
+			c.Rating = 3.5
+			c.Comment = "普通"
+		}, []reviewRevisionChange{
+			{Field: "age", Before: 20, After: 21},
+			{Field: "rating", Before: 4.0, After: 3.5},
+			{Field: "comment", Before: "良い", After: "普通"},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			after := base
+			tc.mutate(&after)
+			got := diffReviewContent(base, after, stores)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("diffReviewContent() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRevisionMatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		revision int
+		want     any
+	}{
+		{"未採番", 0, bson.M{"$in": bson.A{nil, 0, 1}}},
+		{"初版", 1, bson.M{"$in": bson.A{nil, 0, 1}}},
+		{"編集済み", 3, 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := revisionMatch(reviewDocument{Revision: tc.revision}); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("revisionMatch() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
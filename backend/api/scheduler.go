@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	schedulerLeaseName = "scheduler"
+	dailyDigestJobName = "daily-digest"
+	maxSLAReminderTier = 3
+)
+
+type schedulerLeaseDocument struct {
+	ID         string     `bson:"_id"`
+	Holder     string     `bson:"holder,omitempty"`
+	ExpiresAt  *time.Time `bson:"expiresAt,omitempty"`
+	LastRunKey string     `bson:"lastRunKey,omitempty"`
+	LastRunAt  *time.Time `bson:"lastRunAt,omitempty"`
+	Cursor     string     `bson:"cursor,omitempty"`
+	CursorKey  string     `bson:"cursorKey,omitempty"`
+}
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || strings.TrimSpace(host) == "" {
+		host = "api"
+	}
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return host
+	}
+	return host + "-" + hex.EncodeToString(buf)
+}
+
+func (s *server) runScheduler(ctx context.Context) {
+	if s.schedulerInterval <= 0 {
+		return
+	}
+	s.logger.Printf("スケジューラーを開始します instance=%s interval=%s", s.instanceID, s.schedulerInterval)
+
+	ticker := time.NewTicker(s.schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.schedulerTick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *server) schedulerTick(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+	defer cancel()
+
+	leader, err := s.acquireSchedulerLease(ctx)
+	if err != nil {
+		s.logger.Printf("スケジューラーのリース取得に失敗: %v", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	now := time.Now().In(s.location)
+	if err := s.runDailyDigestIfDue(ctx, now); err != nil {
+		s.logger.Printf("日次ダイジェストの送信に失敗: %v", err)
+	}
+	if err := s.sendSLAReminders(ctx, now); err != nil {
+		s.logger.Printf("SLA リマインダーの送信に失敗: %v", err)
+	}
+	if s.maintenanceBusy.CompareAndSwap(false, true) {
+		go func() {
+			defer s.maintenanceBusy.Store(false)
+			s.runMaintenanceJobs(parent)
+		}()
+	}
+}
+
+func (s *server) runMaintenanceJobs(parent context.Context) {
+	jobs := []struct {
+		label string
+		run   func(context.Context) error
+	}{
+		{"コメント指紋の補完", s.backfillCommentFingerprints},
+		{"感想の再スキャン", s.rescanReviewContent},
+		{"店舗統計の再計算", s.refreshStoreStats},
+	}
+	for _, job := range jobs {
+		ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+		if err := job.run(ctx); err != nil {
+			s.logger.Printf("%sに失敗: %v", job.label, err)
+		}
+		cancel()
+	}
+}
+
+func (s *server) runBatchJob(ctx context.Context, name, runKey string, coll *mongo.Collection, filter bson.M, batchSize int64, process func(context.Context, bson.Raw) error) (bool, error) {
+	var job schedulerLeaseDocument
+	err := s.schedulerLeases.FindOne(ctx, bson.M{"_id": name}).Decode(&job)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, err
+	}
+	if job.LastRunKey == runKey {
+		return true, nil
+	}
+
+	query := bson.M{}
+	for key, value := range filter {
+		query[key] = value
+	}
+	if job.CursorKey == runKey {
+		if after, err := primitive.ObjectIDFromHex(job.Cursor); err == nil {
+			query["_id"] = bson.M{"$gt": after}
+		}
+	}
+	cursor, err := coll.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(batchSize))
+	if err != nil {
+		return false, err
+	}
+
+	var last primitive.ObjectID
+	var processed, failed int64
+	for cursor.Next(ctx) {
+		id, ok := cursor.Current.Lookup("_id").ObjectIDOK()
+		if !ok {
+			continue
+		}
+		if err := process(ctx, cursor.Current); err != nil {
+			failed++
+			s.logger.Printf("バッチ処理に失敗 job=%s id=%s err=%v", name, id.Hex(), err)
+		}
+		last = id
+		processed++
+	}
+	cursorErr := cursor.Err()
+	cursor.Close(context.Background())
+	done := cursorErr == nil && processed < batchSize
+
+	saveCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"cursor": last.Hex(), "cursorKey": runKey}}
+	if done {
+		update = bson.M{
+			"$set":   bson.M{"lastRunKey": runKey, "lastRunAt": time.Now().In(s.location)},
+			"$unset": bson.M{"cursor": "", "cursorKey": ""},
+		}
+	} else if last.IsZero() {
+		return false, cursorErr
+	}
+	if _, err := s.schedulerLeases.UpdateOne(saveCtx, bson.M{"_id": name}, update, options.Update().SetUpsert(true)); err != nil {
+		return false, err
+	}
+	if failed > 0 {
+		s.logger.Printf("バッチ処理を継続します job=%s processed=%d failed=%d", name, processed, failed)
+	}
+	return done, cursorErr
+}
+
+func (s *server) acquireSchedulerLease(ctx context.Context) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(3 * s.schedulerInterval)
+	filter := bson.M{
+		"_id": schedulerLeaseName,
+		"$or": bson.A{
+			bson.M{"holder": s.instanceID},
+			bson.M{"expiresAt": bson.M{"$lt": now}},
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"holder": s.instanceID, "expiresAt": expiresAt}}
+
+	_, err := s.schedulerLeases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *server) releaseSchedulerLease() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := s.schedulerLeases.UpdateOne(ctx,
+		bson.M{"_id": schedulerLeaseName, "holder": s.instanceID},
+		bson.M{"$unset": bson.M{"holder": "", "expiresAt": ""}},
+	)
+	if err != nil {
+		s.logger.Printf("スケジューラーのリース解放に失敗: %v", err)
+	}
+}
+
+func (s *server) runDailyDigestIfDue(ctx context.Context, now time.Time) error {
+	if s.discordNotifier == nil || now.Hour() < s.digestHour {
+		return nil
+	}
+
+	runKey := now.Format("2006-01-02")
+	var job schedulerLeaseDocument
+	err := s.schedulerLeases.FindOne(ctx, bson.M{"_id": dailyDigestJobName}).Decode(&job)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if job.LastRunKey == runKey {
+		return nil
+	}
+
+	since := now.Add(-24 * time.Hour)
+	if job.LastRunAt != nil && job.LastRunAt.After(since.Add(-24*time.Hour)) {
+		since = *job.LastRunAt
+	}
+
+	message, err := s.buildDailyDigest(ctx, since, now)
+	if err != nil {
+		return err
+	}
+	if err := s.discordNotifier.send(ctx, notification{Recipient: dailyDigestJobName, Text: message, IdempotencyKey: dailyDigestJobName + ":" + runKey}); err != nil {
+		return err
+	}
+
+	_, err = s.schedulerLeases.UpdateOne(ctx,
+		bson.M{"_id": dailyDigestJobName},
+		bson.M{"$set": bson.M{"lastRunKey": runKey, "lastRunAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *server) buildDailyDigest(ctx context.Context, since, now time.Time) (string, error) {
+	count := func(filter bson.M) (int64, error) {
+		filter["deletedAt"] = bson.M{"$exists": false}
+		return s.reviews.CountDocuments(ctx, filter)
+	}
+
+	created, err := count(bson.M{"createdAt": bson.M{"$gte": since, "$lt": now}})
+	if err != nil {
+		return "", err
+	}
+	approved, err := count(bson.M{"status": "approved", "reviewedAt": bson.M{"$gte": since, "$lt": now}})
+	if err != nil {
+		return "", err
+	}
+	rejected, err := count(bson.M{"status": "rejected", "reviewedAt": bson.M{"$gte": since, "$lt": now}})
+	if err != nil {
+		return "", err
+	}
+	pending, err := count(bson.M{"status": "pending"})
+	if err != nil {
+		return "", err
+	}
+	unpaid, err := count(bson.M{"status": "approved", "reward.status": bson.M{"$in": rewardPayableStatuses}})
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{
+		"📊 **日次ダイジェスト**",
+		fmt.Sprintf("🕐 集計期間: %s 〜 %s", since.In(s.location).Format("2006-01-02 15:04"), now.In(s.location).Format("2006-01-02 15:04")),
+		"",
+		fmt.Sprintf("**新規投稿**\n> %d件", created),
+		fmt.Sprintf("**承認**\n> %d件", approved),
+		fmt.Sprintf("**却下**\n> %d件", rejected),
+		fmt.Sprintf("**未対応 (pending)**\n> %d件", pending),
+		fmt.Sprintf("**PayPay 未送付 (承認済み)**\n> %d件", unpaid),
+	}
+
+	if pending > 0 {
+		var oldest reviewDocument
+		opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+		if err := s.reviews.FindOne(ctx, bson.M{"status": "pending", "deletedAt": bson.M{"$exists": false}}, opts).Decode(&oldest); err == nil {
+			lines = append(lines, fmt.Sprintf("**最も古い未対応**\n> %s (%s経過)", oldest.CreatedAt.In(s.location).Format("2006-01-02 15:04"), formatElapsed(now.Sub(oldest.CreatedAt))))
+		}
+	}
+
+	if link := adminReviewLink(s.adminReviewBaseURL, ""); link != "" {
+		lines = append(lines, "", fmt.Sprintf("🔗 [管理画面](%s)", link))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (s *server) sendSLAReminders(ctx context.Context, now time.Time) error {
+	if s.discordNotifier == nil || s.reviewPendingSLA <= 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"status":    "pending",
+		"createdAt": bson.M{"$lte": now.Add(-s.reviewPendingSLA)},
+		"deletedAt": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := s.reviews.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	var overdue []reviewDocument
+	if err := cursor.All(ctx, &overdue); err != nil {
+		return err
+	}
+
+	byTier := make(map[int][]reviewDocument)
+	for _, review := range overdue {
+		tier := int(now.Sub(review.CreatedAt) / s.reviewPendingSLA)
+		if tier > maxSLAReminderTier {
+			tier = maxSLAReminderTier
+		}
+		if tier <= review.SLAReminderTier {
+			continue
+		}
+		byTier[tier] = append(byTier[tier], review)
+	}
+
+	for tier := maxSLAReminderTier; tier >= 1; tier-- {
+		reviews := byTier[tier]
+		if len(reviews) == 0 {
+			continue
+		}
+
+		message := s.buildSLAReminderMessage(tier, reviews, now)
+		if err := s.discordNotifier.send(ctx, notification{Recipient: fmt.Sprintf("sla-%d", tier), Text: message, IdempotencyKey: fmt.Sprintf("sla-%d:%s", tier, reviews[0].ID.Hex())}); err != nil {
+			return err
+		}
+
+		ids := make(bson.A, 0, len(reviews))
+		for _, review := range reviews {
+			ids = append(ids, review.ID)
+		}
+		if _, err := s.reviews.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"slaReminderTier": tier}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) buildSLAReminderMessage(tier int, reviews []reviewDocument, now time.Time) string {
+	header := "⏰ **未対応アンケートのリマインド**"
+	switch tier {
+	case 2:
+		header = "⚠️ **未対応アンケートが滞留しています**"
+	case maxSLAReminderTier:
+		header = "🚨 **未対応アンケートの対応期限を大幅に超過しています**"
+	}
+
+	lines := []string{}
+	if mention := strings.TrimSpace(s.slaEscalationMention); mention != "" && tier >= 2 {
+		lines = append(lines, mention)
+	}
+	lines = append(lines,
+		header,
+		fmt.Sprintf("SLA %s を超過したアンケートが %d 件あります。", formatElapsed(s.reviewPendingSLA), len(reviews)),
+		"",
+	)
+
+	const maxListed = 10
+	for i, review := range reviews {
+		if i >= maxListed {
+			lines = append(lines, fmt.Sprintf("…ほか %d 件", len(reviews)-maxListed))
+			break
+		}
+		entry := fmt.Sprintf("• %s 投稿 (%s経過)", review.CreatedAt.In(s.location).Format("2006-01-02 15:04"), formatElapsed(now.Sub(review.CreatedAt)))
+		if link := adminReviewLink(s.adminReviewBaseURL, review.ID.Hex()); link != "" {
+			entry += fmt.Sprintf(" [管理画面](%s)", link)
+		}
+		lines = append(lines, entry)
+	}
+
+	lines = append(lines, "", "レビュアーが PayPay の送付を待っています。確認をお願いします。")
+	return strings.Join(lines, "\n")
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%d分", int(d.Minutes()))
+	}
+	hours := int(d.Hours())
+	if hours < 48 {
+		return fmt.Sprintf("%d時間", hours)
+	}
+	return fmt.Sprintf("%d日", hours/24)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const storeStatsRefreshJobName = "store-stats-refresh"
+
+type statFigureDocument struct {
+	Samples   int      `bson:"samples"`
+	Weighted  *float64 `bson:"weighted,omitempty"`
+	Effective float64  `bson:"effectiveSamples"`
+	Median    *float64 `bson:"median,omitempty"`
+	Trimmed   *float64 `bson:"trimmed,omitempty"`
+	TrimCount int      `bson:"trimmedSamples"`
+}
+
+type storeRobustStats struct {
+	HalfLifeDays int                `bson:"halfLifeDays"`
+	TrimPercent  int                `bson:"trimPercent"`
+	Rating       statFigureDocument `bson:"rating"`
+	Earning      statFigureDocument `bson:"averageEarning"`
+	WaitTime     statFigureDocument `bson:"waitTimeHours"`
+	ComputedAt   *time.Time         `bson:"computedAt,omitempty"`
+}
+
+type statFigureResponse struct {
+	Mean             *float64 `json:"mean"`
+	RecencyWeighted  *float64 `json:"recencyWeighted"`
+	Median           *float64 `json:"median"`
+	TrimmedMean      *float64 `json:"trimmedMean"`
+	Samples          int      `json:"samples"`
+	EffectiveSamples float64  `json:"effectiveSamples"`
+	TrimmedSamples   int      `json:"trimmedSamples"`
+}
+
+type storeStatisticsResponse struct {
+	HalfLifeDays   int                `json:"halfLifeDays"`
+	TrimPercent    int                `json:"trimPercent"`
+	Rating         statFigureResponse `json:"rating"`
+	AverageEarning statFigureResponse `json:"averageEarning"`
+	WaitTimeHours  statFigureResponse `json:"waitTimeHours"`
+	ComputedAt     *time.Time         `json:"computedAt,omitempty"`
+}
+
+type weightedSample struct {
+	value  float64
+	weight float64
+}
+
+func roundStat(value float64) *float64 {
+	rounded := math.Round(value*10) / 10
+	return &rounded
+}
+
+// reviewObservedAt は訪問時期 (「2024年8月」または旧形式の「2024-08」) を観測時点とし、読めなければ投稿日時を使う。
+func reviewObservedAt(review reviewDocument, loc *time.Location) time.Time {
+	period := strings.TrimSpace(review.Period)
+	for _, layout := range []string{"2006年1月", "2006-01"} {
+		if t, err := time.ParseInLocation(layout, period, loc); err == nil {
+			return t
+		}
+	}
+	return review.CreatedAt
+}
+
+func recencyWeight(observedAt, now time.Time, halfLifeDays int) float64 {
+	if halfLifeDays <= 0 {
+		return 1
+	}
+	ageDays := now.Sub(observedAt).Hours() / 24
+	if ageDays < 0 {
+		ageDays = 0
+	}
+	return math.Pow(0.5, ageDays/float64(halfLifeDays))
+}
+
+func summarizeSamples(samples []weightedSample, trimPercent int) statFigureDocument {
+	figure := statFigureDocument{Samples: len(samples)}
+	if len(samples) == 0 {
+		return figure
+	}
+
+	var sumW, sumWV, sumW2 float64
+	for _, sample := range samples {
+		sumW += sample.weight
+		sumWV += sample.weight * sample.value
+		sumW2 += sample.weight * sample.weight
+	}
+	if sumW > 0 {
+		figure.Weighted = roundStat(sumWV / sumW)
+		figure.Effective = math.Round(sumW*sumW/sumW2*10) / 10
+	}
+
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, sample.value)
+	}
+	sort.Float64s(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		figure.Median = roundStat(values[mid])
+	} else {
+		figure.Median = roundStat((values[mid-1] + values[mid]) / 2)
+	}
+
+	cut := len(values) * trimPercent / 100
+	trimmed := values[cut : len(values)-cut]
+	if len(trimmed) == 0 {
+		trimmed = values
+		cut = 0
+	}
+	var sum float64
+	for _, value := range trimmed {
+		sum += value
+	}
+	figure.Trimmed = roundStat(sum / float64(len(trimmed)))
+	figure.TrimCount = cut * 2
+	return figure
+}
+
+func (s *server) computeRobustStats(ctx context.Context, match bson.M, now time.Time) (storeRobustStats, error) {
+	stats := storeRobustStats{
+		HalfLifeDays: s.statsHalfLifeDays,
+		TrimPercent:  s.statsTrimPercent,
+		ComputedAt:   &now,
+	}
+	cursor, err := s.reviews.Find(ctx, match, options.Find().SetProjection(bson.M{
+		"period":         1,
+		"createdAt":      1,
+		"rating":         1,
+		"averageEarning": 1,
+		"waitTimeHours":  1,
+	}))
+	if err != nil {
+		return stats, err
+	}
+	var reviews []reviewDocument
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return stats, err
+	}
+
+	var ratings, earnings, waits []weightedSample
+	for _, review := range reviews {
+		weight := recencyWeight(reviewObservedAt(review, s.location), now, s.statsHalfLifeDays)
+		ratings = append(ratings, weightedSample{value: review.Rating, weight: weight})
+		if review.AverageEarning != nil {
+			earnings = append(earnings, weightedSample{value: float64(*review.AverageEarning), weight: weight})
+		}
+		if review.WaitTimeHours != nil {
+			waits = append(waits, weightedSample{value: float64(*review.WaitTimeHours), weight: weight})
+		}
+	}
+	stats.Rating = summarizeSamples(ratings, s.statsTrimPercent)
+	stats.Earning = summarizeSamples(earnings, s.statsTrimPercent)
+	stats.WaitTime = summarizeSamples(waits, s.statsTrimPercent)
+	return stats, nil
+}
+
+func statFigureToResponse(mean *float64, figure statFigureDocument) statFigureResponse {
+	response := statFigureResponse{
+		RecencyWeighted:  figure.Weighted,
+		Median:           figure.Median,
+		TrimmedMean:      figure.Trimmed,
+		Samples:          figure.Samples,
+		EffectiveSamples: figure.Effective,
+		TrimmedSamples:   figure.TrimCount,
+	}
+	if mean != nil {
+		response.Mean = roundStat(*mean)
+	}
+	return response
+}
+
+func buildStoreStatistics(store storeDocument) *storeStatisticsResponse {
+	if store.RobustStats.ComputedAt == nil {
+		return nil
+	}
+	robust := store.RobustStats
+	return &storeStatisticsResponse{
+		HalfLifeDays:   robust.HalfLifeDays,
+		TrimPercent:    robust.TrimPercent,
+		Rating:         statFigureToResponse(store.Stats.AvgRating, robust.Rating),
+		AverageEarning: statFigureToResponse(store.Stats.AvgEarning, robust.Earning),
+		WaitTimeHours:  statFigureToResponse(store.Stats.AvgWaitTime, robust.WaitTime),
+		ComputedAt:     robust.ComputedAt,
+	}
+}
+
+func (s *server) refreshStoreStats(ctx context.Context) error {
+	if s.statsHalfLifeDays <= 0 {
+		return nil
+	}
+	runKey := time.Now().In(s.location).Format("2006-01-02")
+	_, err := s.runBatchJob(ctx, storeStatsRefreshJobName, runKey, s.stores, bson.M{"stats.reviewCount": bson.M{"$gt": 0}}, 100, func(ctx context.Context, raw bson.Raw) error {
+		storeID, ok := raw.Lookup("_id").ObjectIDOK()
+		if !ok {
+			return nil
+		}
+		return s.recalculateStoreStats(ctx, storeID)
+	})
+	return err
+}
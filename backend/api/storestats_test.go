@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarizeSamples(t *testing.T) {
+	uniform := func(values ...float64) []weightedSample {
+		samples := make([]weightedSample, 0, len(values))
+		for _, value := range values {
+			samples = append(samples, weightedSample{value: value, weight: 1})
+		}
+		return samples
+	}
+	cases := []struct {
+		name         string
+		samples      []weightedSample
+		trimPercent  int
+		wantWeighted float64
+		wantMedian   float64
+		wantTrimmed  float64
+		wantEffect   float64
+		wantTrimmedN int
+	}{
+		{"1件", uniform(5), 10, 5, 5, 5, 1, 0},
+		{"偶数件の中央値", uniform(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 10, 5.5, 5.5, 5.5, 10, 2},
+		{"外れ値を刈り込む", uniform(1, 2, 3, 100), 25, 26.5, 2.5, 2.5, 4, 2},
+		{"刈り込みなし", uniform(3, 1, 2), 0, 2, 2, 2, 3, 0},
+		{"件数が少なく刈り込めない", uniform(4, 8), 40, 6, 6, 6, 2, 0},
+		{"新しい投稿を重視", []weightedSample{{value: 2, weight: 1}, {value: 4, weight: 3}}, 0, 3.5, 3, 3, 1.6, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := summarizeSamples(tc.samples, tc.trimPercent)
+			if got.Samples != len(tc.samples) {
+				t.Errorf("Samples = %d, want %d", got.Samples, len(tc.samples))
+			}
+			checkStat(t, "Weighted", got.Weighted, tc.wantWeighted)
+			checkStat(t, "Median", got.Median, tc.wantMedian)
+			checkStat(t, "Trimmed", got.Trimmed, tc.wantTrimmed)
+			if got.Effective != tc.wantEffect {
+				t.Errorf("Effective = %v, want %v", got.Effective, tc.wantEffect)
+			}
+			if got.TrimCount != tc.wantTrimmedN {
+				t.Errorf("TrimCount = %d, want %d", got.TrimCount, tc.wantTrimmedN)
+			}
+		})
+	}
+
+	empty := summarizeSamples(nil, 10)
+	if empty.Samples != 0 || empty.Weighted != nil || empty.Median != nil || empty.Trimmed != nil {
+		t.Errorf("summarizeSamples(nil) = %+v, want zero figure", empty)
+	}
+}
+
+func checkStat(t *testing.T, name string, got *float64, want float64) {
+	t.Helper()
+	if got == nil {
+		t.Errorf("%s = nil, want %v", name, want)
+		return
+	}
+	if math.Abs(*got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, *got, want)
+	}
+}
+
+func TestRecencyWeight(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		observed time.Time
+		halfLife int
+		want     float64
+	}{
+		{"半減期0は重み付けなし", now.AddDate(-3, 0, 0), 0, 1},
+		{"当日", now, 365, 1},
+		{"半減期ちょうど", now.AddDate(0, 0, -30), 30, 0.5},
+		{"半減期の2倍", now.AddDate(0, 0, -60), 30, 0.25},
+		{"未来の日付", now.AddDate(0, 0, 10), 30, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := recencyWeight(tc.observed, now, tc.halfLife); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("recencyWeight() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReviewObservedAt(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, jst)
+	cases := []struct {
+		name   string
+		period string
+		want   time.Time
+	}{
+		{"年月表記", "2024年8月", time.Date(2024, 8, 1, 0, 0, 0, 0, jst)},
+		{"2桁の月", "2024年12月", time.Date(2024, 12, 1, 0, 0, 0, 0, jst)},
+		{"旧形式", "2024-08", time.Date(2024, 8, 1, 0, 0, 0, 0, jst)},
+		{"空白を除去", " 2024年8月 ", time.Date(2024, 8, 1, 0, 0, 0, 0, jst)},
+		{"未入力は投稿日時", "", createdAt},
+		{"形式不正は投稿日時", "去年の夏", createdAt},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := reviewObservedAt(reviewDocument{Period: tc.period, CreatedAt: createdAt}, jst)
+			if !got.Equal(tc.want) {
+				t.Errorf("reviewObservedAt(%q) = %v, want %v", tc.period, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	moderationActionAutoApprove  = "auto_approve"
+	moderationActionAutoHold     = "auto_hold"
+	moderationActionSecondReview = "second_review"
+
+	reviewStatusOnHold = "on_hold"
+	autoModeratorName  = "auto"
+)
+
+var moderationActions = []string{
+	moderationActionAutoApprove,
+	moderationActionAutoHold,
+	moderationActionSecondReview,
+}
+
+type reviewerProfileDocument struct {
+	ReviewerID        string     `bson:"_id" json:"reviewerId"`
+	TotalCount        int        `bson:"totalCount" json:"totalCount"`
+	ApprovedCount     int        `bson:"approvedCount" json:"approvedCount"`
+	RejectedCount     int        `bson:"rejectedCount" json:"rejectedCount"`
+	NeedsChangesCount int        `bson:"needsChangesCount" json:"needsChangesCount"`
+	WithdrawnCount    int        `bson:"withdrawnCount" json:"withdrawnCount"`
+	FlaggedCount      int        `bson:"flaggedCount" json:"flaggedCount"`
+	FirstSubmittedAt  *time.Time `bson:"firstSubmittedAt,omitempty" json:"firstSubmittedAt,omitempty"`
+	LastSubmittedAt   *time.Time `bson:"lastSubmittedAt,omitempty" json:"lastSubmittedAt,omitempty"`
+	AccountAgeDays    int        `bson:"accountAgeDays" json:"accountAgeDays"`
+	TrustScore        float64    `bson:"trustScore" json:"trustScore"`
+	UpdatedAt         time.Time  `bson:"updatedAt" json:"updatedAt"`
+}
+
+type moderationConditions struct {
+	MinTrustScore     *float64 `bson:"minTrustScore,omitempty" json:"minTrustScore,omitempty"`
+	MaxTrustScore     *float64 `bson:"maxTrustScore,omitempty" json:"maxTrustScore,omitempty"`
+	MinApprovedCount  *int     `bson:"minApprovedCount,omitempty" json:"minApprovedCount,omitempty"`
+	MaxRejectedCount  *int     `bson:"maxRejectedCount,omitempty" json:"maxRejectedCount,omitempty"`
+	MinAccountAgeDays *int     `bson:"minAccountAgeDays,omitempty" json:"minAccountAgeDays,omitempty"`
+	MinAnomalyScore   *float64 `bson:"minAnomalyScore,omitempty" json:"minAnomalyScore,omitempty"`
+	MaxAnomalyScore   *float64 `bson:"maxAnomalyScore,omitempty" json:"maxAnomalyScore,omitempty"`
+	RequireNoFlags    bool     `bson:"requireNoFlags,omitempty" json:"requireNoFlags,omitempty"`
+	FlagsAny          []string `bson:"flagsAny,omitempty" json:"flagsAny,omitempty"`
+	MinCommentLength  *int     `bson:"minCommentLength,omitempty" json:"minCommentLength,omitempty"`
+}
+
+type moderationRuleDocument struct {
+	ID         primitive.ObjectID   `bson:"_id"`
+	Name       string               `bson:"name"`
+	Action     string               `bson:"action"`
+	Priority   int                  `bson:"priority"`
+	Enabled    bool                 `bson:"enabled"`
+	Conditions moderationConditions `bson:"conditions"`
+	CreatedAt  time.Time            `bson:"createdAt"`
+	UpdatedAt  time.Time            `bson:"updatedAt"`
+}
+
+type moderationRuleRequest struct {
+	Name       string               `json:"name"`
+	Action     string               `json:"action"`
+	Priority   int                  `json:"priority"`
+	Enabled    *bool                `json:"enabled"`
+	Conditions moderationConditions `json:"conditions"`
+}
+
+type moderationRuleResponse struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Action     string               `json:"action"`
+	Priority   int                  `json:"priority"`
+	Enabled    bool                 `json:"enabled"`
+	Conditions moderationConditions `json:"conditions"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	UpdatedAt  time.Time            `json:"updatedAt"`
+}
+
+type moderationApprovalDocument struct {
+	By string    `bson:"by" json:"by"`
+	At time.Time `bson:"at" json:"at"`
+}
+
+type reviewModerationDocument struct {
+	RuleID            primitive.ObjectID           `bson:"ruleId,omitempty"`
+	RuleName          string                       `bson:"ruleName,omitempty"`
+	Action            string                       `bson:"action"`
+	TrustScore        float64                      `bson:"trustScore"`
+	RequiredApprovals int                          `bson:"requiredApprovals,omitempty"`
+	Approvals         []moderationApprovalDocument `bson:"approvals,omitempty"`
+	DecidedAt         time.Time                    `bson:"decidedAt"`
+}
+
+type moderationResponse struct {
+	RuleID            string                       `json:"ruleId,omitempty"`
+	RuleName          string                       `json:"ruleName,omitempty"`
+	Action            string                       `json:"action"`
+	TrustScore        float64                      `json:"trustScore"`
+	RequiredApprovals int                          `json:"requiredApprovals,omitempty"`
+	Approvals         []moderationApprovalDocument `json:"approvals,omitempty"`
+	DecidedAt         time.Time                    `json:"decidedAt"`
+}
+
+func buildModerationResponse(doc *reviewModerationDocument) *moderationResponse {
+	if doc == nil {
+		return nil
+	}
+	return &moderationResponse{
+		RuleID:            objectIDHex(doc.RuleID),
+		RuleName:          doc.RuleName,
+		Action:            doc.Action,
+		TrustScore:        doc.TrustScore,
+		RequiredApprovals: doc.RequiredApprovals,
+		Approvals:         doc.Approvals,
+		DecidedAt:         doc.DecidedAt,
+	}
+}
+
+func moderationRuleToResponse(doc moderationRuleDocument) moderationRuleResponse {
+	return moderationRuleResponse{
+		ID:         doc.ID.Hex(),
+		Name:       doc.Name,
+		Action:     doc.Action,
+		Priority:   doc.Priority,
+		Enabled:    doc.Enabled,
+		Conditions: doc.Conditions,
+		CreatedAt:  doc.CreatedAt,
+		UpdatedAt:  doc.UpdatedAt,
+	}
+}
+
+func computeTrustScore(profile reviewerProfileDocument) float64 {
+	score := 50.0
+	score += math.Min(float64(profile.ApprovedCount)*5, 40)
+	score -= float64(profile.RejectedCount) * 15
+	score -= float64(profile.NeedsChangesCount) * 3
+	score -= float64(profile.FlaggedCount) * 10
+	score += math.Min(float64(profile.AccountAgeDays)/30*2, 10)
+	return math.Round(math.Max(0, math.Min(100, score))*10) / 10
+}
+
+func (s *server) refreshReviewerProfile(ctx context.Context, reviewerID string) (reviewerProfileDocument, error) {
+	reviewerID = strings.TrimSpace(reviewerID)
+	profile := reviewerProfileDocument{ReviewerID: reviewerID}
+	if reviewerID == "" {
+		return profile, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"reviewerId": reviewerID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               nil,
+			"totalCount":        bson.M{"$sum": 1},
+			"approvedCount":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "approved"}}, 1, 0}}},
+			"rejectedCount":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "rejected"}}, 1, 0}}},
+			"needsChangesCount": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", reviewStatusNeedsChanges}}, 1, 0}}},
+			"withdrawnCount":    bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", reviewStatusWithdrawn}}, 1, 0}}},
+			"flaggedCount": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$or": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$flags", bson.A{}}}}, 0}},
+				bson.M{"$eq": bson.A{"$anomaly.outlier", true}},
+			}}, 1, 0}}},
+			"firstSubmittedAt": bson.M{"$min": "$createdAt"},
+			"lastSubmittedAt":  bson.M{"$max": "$createdAt"},
+		}}},
+	}
+	cursor, err := s.reviews.Aggregate(ctx, pipeline)
+	if err != nil {
+		return profile, err
+	}
+	defer cursor.Close(ctx)
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&profile); err != nil {
+			return profile, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return profile, err
+	}
+
+	now := time.Now().In(s.location)
+	profile.ReviewerID = reviewerID
+	if profile.FirstSubmittedAt != nil {
+		profile.AccountAgeDays = int(now.Sub(*profile.FirstSubmittedAt).Hours() / 24)
+	}
+	profile.TrustScore = computeTrustScore(profile)
+	profile.UpdatedAt = now
+
+	_, err = s.reviewerProfiles.ReplaceOne(ctx, bson.M{"_id": reviewerID}, profile, options.Replace().SetUpsert(true))
+	return profile, err
+}
+
+func (s *server) loadModerationRules(ctx context.Context, onlyEnabled bool) ([]moderationRuleDocument, error) {
+	filter := bson.M{}
+	if onlyEnabled {
+		filter["enabled"] = true
+	}
+	cursor, err := s.moderationRules.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []moderationRuleDocument{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func moderationRuleMatches(conditions moderationConditions, profile reviewerProfileDocument, review reviewDocument) bool {
+	if conditions.MinTrustScore != nil && profile.TrustScore < *conditions.MinTrustScore {
+		return false
+	}
+	if conditions.MaxTrustScore != nil && profile.TrustScore > *conditions.MaxTrustScore {
+		return false
+	}
+	if conditions.MinApprovedCount != nil && profile.ApprovedCount < *conditions.MinApprovedCount {
+		return false
+	}
+	if conditions.MaxRejectedCount != nil && profile.RejectedCount > *conditions.MaxRejectedCount {
+		return false
+	}
+	if conditions.MinAccountAgeDays != nil && profile.AccountAgeDays < *conditions.MinAccountAgeDays {
+		return false
+	}
+	anomalyScore := 0.0
+	if review.Anomaly != nil {
+		anomalyScore = review.Anomaly.Score
+	}
+	if conditions.MinAnomalyScore != nil && anomalyScore < *conditions.MinAnomalyScore {
+		return false
+	}
+	if conditions.MaxAnomalyScore != nil && anomalyScore > *conditions.MaxAnomalyScore {
+		return false
+	}
+	if conditions.RequireNoFlags && len(review.Flags) > 0 {
+		return false
+	}
+	if len(conditions.FlagsAny) > 0 {
+		matched := false
+		for _, flag := range review.Flags {
+			if contains(conditions.FlagsAny, flag.Code) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if conditions.MinCommentLength != nil && len([]rune(strings.TrimSpace(review.Comment))) < *conditions.MinCommentLength {
+		return false
+	}
+	return true
+}
+
+func (s *server) applyModerationRules(ctx context.Context, review reviewDocument, store storeDocument) reviewDocument {
+	profile, err := s.refreshReviewerProfile(ctx, review.ReviewerID)
+	if err != nil {
+		s.logger.Printf("レビュアープロフィールの更新に失敗 reviewerId=%s err=%v", review.ReviewerID, err)
+		return review
+	}
+	rules, err := s.loadModerationRules(ctx, true)
+	if err != nil {
+		s.logger.Printf("自動審査ルールの取得に失敗: %v", err)
+		return review
+	}
+
+	var fired *moderationRuleDocument
+	for i := range rules {
+		if moderationRuleMatches(rules[i].Conditions, profile, review) {
+			fired = &rules[i]
+			break
+		}
+	}
+	if fired == nil {
+		return review
+	}
+
+	now := time.Now().In(s.location)
+	decision := reviewModerationDocument{
+		RuleID:     fired.ID,
+		RuleName:   fired.Name,
+		Action:     fired.Action,
+		TrustScore: profile.TrustScore,
+		DecidedAt:  now,
+	}
+	update := bson.M{"moderation": decision, "updatedAt": now}
+	switch fired.Action {
+	case moderationActionAutoApprove:
+		update["status"] = "approved"
+		update["reviewedBy"] = autoModeratorName + ":" + fired.Name
+		update["reviewedAt"] = now
+		update["publishedRevision"] = currentRevision(review)
+		if review.Reward.CommittedAt == nil {
+			if _, err := s.applyRewardDecision(ctx, review, update, autoModeratorName+":"+fired.Name, now); err != nil {
+				s.logger.Printf("自動承認時の謝礼ポリシー評価に失敗 id=%s err=%v", review.ID.Hex(), err)
+			}
+		}
+	case moderationActionAutoHold:
+		update["status"] = reviewStatusOnHold
+		update["statusNote"] = "自動審査ルール「" + fired.Name + "」により保留"
+	case moderationActionSecondReview:
+		decision.RequiredApprovals = 2
+		update["moderation"] = decision
+	}
+
+	var updated reviewDocument
+	filter := bson.M{"_id": review.ID, "status": review.Status}
+	result := s.reviews.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&updated); err != nil {
+		s.logger.Printf("自動審査結果の保存に失敗 id=%s rule=%q err=%v", review.ID.Hex(), fired.Name, err)
+		return review
+	}
+	if fired.Action == moderationActionAutoApprove && review.Reward.CommittedAt == nil {
+		updated = s.recheckRewardCommit(ctx, updated)
+	}
+
+	s.recordReviewAudit(ctx, updated.ID, "auto_moderation", autoModeratorName, auditActorAdmin, bson.M{
+		"ruleId":     fired.ID,
+		"ruleName":   fired.Name,
+		"action":     fired.Action,
+		"trustScore": profile.TrustScore,
+	})
+	s.logger.Printf("auto moderation id=%s rule=%q action=%s trust=%.1f", updated.ID.Hex(), fired.Name, fired.Action, profile.TrustScore)
+
+	if strings.TrimSpace(updated.Status) != strings.TrimSpace(review.Status) {
+		if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+			s.logger.Printf("自動審査後の店舗統計更新に失敗 id=%s err=%v", updated.ID.Hex(), err)
+		}
+		s.handleReviewStatusTransition(ctx, review, updated, store)
+	}
+	return updated
+}
+
+func reviewAwaitsModerator(review reviewDocument) bool {
+	switch strings.TrimSpace(review.Status) {
+	case "pending", reviewStatusOnHold:
+		return true
+	}
+	return false
+}
+
+func (s *server) recordModeratorApproval(ctx context.Context, w http.ResponseWriter, existing reviewDocument, reviewedBy string) (reviewDocument, bool, bool) {
+	moderation := existing.Moderation
+	if moderation == nil || moderation.RequiredApprovals < 2 || strings.TrimSpace(existing.Status) == "approved" {
+		return existing, false, true
+	}
+	reviewedBy = strings.TrimSpace(reviewedBy)
+	if reviewedBy == "" {
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "二重承認が必要なレビューは承認者名を指定してください"})
+		return existing, false, false
+	}
+	for _, approval := range moderation.Approvals {
+		if approval.By == reviewedBy {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "同じ管理者による二重承認はできません"})
+			return existing, false, false
+		}
+	}
+
+	approval := moderationApprovalDocument{By: reviewedBy, At: time.Now().In(s.location)}
+	if len(moderation.Approvals)+1 >= moderation.RequiredApprovals {
+		existing.Moderation.Approvals = append(existing.Moderation.Approvals, approval)
+		return existing, false, true
+	}
+
+	var updated reviewDocument
+	result := s.reviews.FindOneAndUpdate(ctx,
+		bson.M{"_id": existing.ID, "status": existing.Status},
+		bson.M{"$push": bson.M{"moderation.approvals": approval}, "$set": bson.M{"updatedAt": approval.At}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if err := result.Decode(&updated); err != nil {
+		s.logger.Printf("一次承認の記録に失敗 id=%s err=%v", existing.ID.Hex(), err)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "承認の記録に失敗しました"})
+		return existing, false, false
+	}
+	s.recordReviewAudit(ctx, updated.ID, "first_approval", reviewedBy, auditActorAdmin, nil)
+	return updated, true, true
+}
+
+func validateModerationRule(req moderationRuleRequest) (moderationRuleRequest, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Action = strings.TrimSpace(req.Action)
+	if req.Name == "" {
+		return req, errors.New("ルール名は必須です")
+	}
+	if !contains(moderationActions, req.Action) {
+		return req, errors.New("actionはauto_approve, auto_hold, second_reviewのいずれかを指定してください")
+	}
+	for _, flag := range req.Conditions.FlagsAny {
+		if _, ok := reviewFlagLabels[flag]; !ok {
+			return req, errors.New("未対応のフラグです: " + flag)
+		}
+	}
+	if req.Action == moderationActionAutoApprove && req.Conditions.MinTrustScore == nil && !req.Conditions.RequireNoFlags {
+		return req, errors.New("自動承認ルールには信頼スコアの下限かフラグなし条件が必要です")
+	}
+	return req, nil
+}
+
+func (s *server) adminModerationRuleListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		rules, err := s.loadModerationRules(ctx, false)
+		if err != nil {
+			s.logger.Printf("admin moderation rule list failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "自動審査ルールの取得に失敗しました"})
+			return
+		}
+		items := make([]moderationRuleResponse, 0, len(rules))
+		for _, rule := range rules {
+			items = append(items, moderationRuleToResponse(rule))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func (s *server) adminModerationRuleCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req moderationRuleRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		req, err := validateModerationRule(req)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		doc := moderationRuleDocument{
+			ID:         primitive.NewObjectID(),
+			Name:       req.Name,
+			Action:     req.Action,
+			Priority:   req.Priority,
+			Enabled:    req.Enabled == nil || *req.Enabled,
+			Conditions: req.Conditions,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if _, err := s.moderationRules.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("admin moderation rule create failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "自動審査ルールの作成に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin moderation rule create success id=%s name=%q action=%s", doc.ID.Hex(), doc.Name, doc.Action)
+		s.writeJSON(w, http.StatusCreated, moderationRuleToResponse(doc))
+	}
+}
+
+func (s *server) adminModerationRuleUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ルールIDの形式が不正です"})
+			return
+		}
+
+		var req moderationRuleRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+		req, err = validateModerationRule(req)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		update := bson.M{
+			"name":       req.Name,
+			"action":     req.Action,
+			"priority":   req.Priority,
+			"conditions": req.Conditions,
+			"updatedAt":  time.Now().In(s.location),
+		}
+		if req.Enabled != nil {
+			update["enabled"] = *req.Enabled
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var updated moderationRuleDocument
+		result := s.moderationRules.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "自動審査ルールが見つかりません"})
+				return
+			}
+			s.logger.Printf("admin moderation rule update failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "自動審査ルールの更新に失敗しました"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, moderationRuleToResponse(updated))
+	}
+}
+
+func (s *server) adminModerationRuleDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ルールIDの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		result, err := s.moderationRules.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			s.logger.Printf("admin moderation rule delete failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "自動審査ルールの削除に失敗しました"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "自動審査ルールが見つかりません"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) adminReviewerProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewerID := strings.TrimSpace(chi.URLParam(r, "id"))
+		if reviewerID == "" {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "レビュアーIDが指定されていません"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		profile, err := s.refreshReviewerProfile(ctx, reviewerID)
+		if err != nil {
+			s.logger.Printf("admin reviewer profile failed reviewerId=%s err=%v", reviewerID, err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビュアー情報の取得に失敗しました"})
+			return
+		}
+		if profile.TotalCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "レビュアーが見つかりません"})
+			return
+		}
+		s.writeJSON(w, http.StatusOK, profile)
+	}
+}
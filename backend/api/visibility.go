@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type reviewVisibilityRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func publicReviewFilter(filter bson.M) bson.M {
+	filter["status"] = "approved"
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+func isReviewPublic(review reviewDocument) bool {
+	return strings.TrimSpace(review.Status) == "approved" && review.DeletedAt == nil
+}
+
+func decodeVisibilityRequest(r *http.Request) (reviewVisibilityRequest, error) {
+	var req reviewVisibilityRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return req, err
+	}
+	req.Actor = strings.TrimSpace(req.Actor)
+	req.Reason = strings.TrimSpace(req.Reason)
+	return req, nil
+}
+
+func (s *server) changeReviewVisibility(ctx context.Context, existing reviewDocument, update bson.M, action, actor string, detail bson.M) (reviewDocument, error) {
+	if set, ok := update["$set"].(bson.M); ok {
+		set["updatedAt"] = time.Now().In(s.location)
+	}
+	var updated reviewDocument
+	result := s.reviews.FindOneAndUpdate(ctx, bson.M{"_id": existing.ID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := result.Decode(&updated); err != nil {
+		return reviewDocument{}, err
+	}
+	s.recordReviewAudit(ctx, updated.ID, action, actor, auditActorAdmin, detail)
+	if err := s.recalculateStoreStats(ctx, updated.StoreID); err != nil {
+		s.logger.Printf("公開状態変更後の店舗統計更新に失敗 id=%s err=%v", updated.ID.Hex(), err)
+	}
+	if isReviewPublic(existing) && !isReviewPublic(updated) {
+		s.releaseRewardLink(ctx, updated, actor, action)
+	}
+	return updated, nil
+}
+
+func (s *server) unpublishReview(ctx context.Context, existing reviewDocument, actor, reason string) (reviewDocument, error) {
+	now := time.Now().In(s.location)
+	statusNote := reason
+	if statusNote == "" {
+		statusNote = "管理者により非公開"
+	}
+	return s.changeReviewVisibility(ctx, existing, bson.M{"$set": bson.M{
+		"status":     reviewStatusUnpublished,
+		"statusNote": statusNote,
+		"reviewedBy": actor,
+		"reviewedAt": now,
+	}}, "admin_unpublish", actor, bson.M{"reason": reason})
+}
+
+func (s *server) softDeleteReview(ctx context.Context, existing reviewDocument, actor, reason string) (reviewDocument, error) {
+	set := bson.M{
+		"deletedAt": time.Now().In(s.location),
+		"deletedBy": actor,
+	}
+	if reason != "" {
+		set["deleteReason"] = reason
+	}
+	return s.changeReviewVisibility(ctx, existing, bson.M{"$set": set}, "admin_delete", actor, bson.M{"reason": reason})
+}
+
+func (s *server) restoreReview(ctx context.Context, existing reviewDocument, actor string) (reviewDocument, error) {
+	set := bson.M{}
+	if existing.Status == reviewStatusUnpublished {
+		set["status"] = "approved"
+		set["statusNote"] = ""
+		set["reviewedBy"] = actor
+		set["reviewedAt"] = time.Now().In(s.location)
+	}
+	return s.changeReviewVisibility(ctx, existing, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"deletedAt": "", "deletedBy": "", "deleteReason": ""},
+	}, "admin_restore", actor, bson.M{"previousStatus": existing.Status, "wasDeleted": existing.DeletedAt != nil})
+}
+
+func (s *server) respondVisibilityChange(ctx context.Context, w http.ResponseWriter, existing, updated reviewDocument) {
+	store, err := s.getStoreByID(ctx, updated.StoreID)
+	if err != nil {
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "店舗情報の取得に失敗しました"})
+		return
+	}
+	s.refreshAfterVisibilityChange(ctx, existing, updated)
+	s.writeJSON(w, http.StatusOK, buildAdminReviewResponse(updated, store))
+}
+
+func (s *server) refreshAfterVisibilityChange(ctx context.Context, existing, updated reviewDocument) {
+	if _, err := s.refreshReviewerProfile(ctx, updated.ReviewerID); err != nil {
+		s.logger.Printf("レビュアープロフィールの更新に失敗 reviewerId=%s err=%v", updated.ReviewerID, err)
+	}
+	if isReviewFingerprinted(existing) != isReviewFingerprinted(updated) {
+		s.indexReviewComment(ctx, updated)
+	}
+}
+
+func (s *server) adminReviewUnpublishHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := decodeVisibilityRequest(r)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if existing.Status != "approved" {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "公開中のレビューのみ非公開にできます"})
+			return
+		}
+
+		updated, err := s.unpublishReview(ctx, existing, req.Actor, req.Reason)
+		if err != nil {
+			s.logger.Printf("レビューの非公開化に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの非公開化に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin review unpublish id=%s by=%q", updated.ID.Hex(), req.Actor)
+		s.respondVisibilityChange(ctx, w, existing, updated)
+	}
+}
+
+func (s *server) adminReviewDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := decodeVisibilityRequest(r)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if existing.DeletedAt != nil {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューは既に削除されています"})
+			return
+		}
+
+		updated, err := s.softDeleteReview(ctx, existing, req.Actor, req.Reason)
+		if err != nil {
+			s.logger.Printf("レビューの削除に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの削除に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin review delete id=%s by=%q", updated.ID.Hex(), req.Actor)
+		s.respondVisibilityChange(ctx, w, existing, updated)
+	}
+}
+
+func (s *server) adminReviewRestoreHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := decodeVisibilityRequest(r)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, ok := s.findAdminReview(ctx, w, r)
+		if !ok {
+			return
+		}
+		if existing.DeletedAt == nil && existing.Status != reviewStatusUnpublished {
+			s.writeJSON(w, http.StatusConflict, map[string]string{"error": "このレビューは削除・非公開されていません"})
+			return
+		}
+
+		updated, err := s.restoreReview(ctx, existing, req.Actor)
+		if err != nil {
+			s.logger.Printf("レビューの復元に失敗 id=%s err=%v", existing.ID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "レビューの復元に失敗しました"})
+			return
+		}
+		s.logger.Printf("admin review restore id=%s by=%q", updated.ID.Hex(), req.Actor)
+		s.respondVisibilityChange(ctx, w, existing, updated)
+	}
+}
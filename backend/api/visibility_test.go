@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPublicReviewFilter(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter bson.M
+		want   bson.M
+	}{
+		{"空の条件", bson.M{}, bson.M{"status": "approved", "deletedAt": bson.M{"$exists": false}}},
+		{"既存条件を保持", bson.M{"storeId": "s1"}, bson.M{"storeId": "s1", "status": "approved", "deletedAt": bson.M{"$exists": false}}},
+		{"status の指定は公開に固定", bson.M{"status": "pending"}, bson.M{"status": "approved", "deletedAt": bson.M{"$exists": false}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := publicReviewFilter(tc.filter); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("publicReviewFilter() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsReviewPublic(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		review reviewDocument
+		want   bool
+	}{
+		{"承認済み", reviewDocument{Status: "approved"}, true},
+		{"審査中", reviewDocument{Status: "pending"}, false},
+		{"非公開", reviewDocument{Status: reviewStatusUnpublished}, false},
+		{"削除済み", reviewDocument{Status: "approved", DeletedAt: &deletedAt}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isReviewPublic(tc.review); got != tc.want {
+				t.Errorf("isReviewPublic() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
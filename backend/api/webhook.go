@@ -0,0 +1,651 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookEventReviewCreated  = "review.created"
+	webhookEventReviewApproved = "review.approved"
+	webhookEventReviewRejected = "review.rejected"
+	webhookEventRewardSent     = "reward.sent"
+	webhookEventStoreCreated   = "store.created"
+)
+
+var webhookEvents = []string{
+	webhookEventReviewCreated,
+	webhookEventReviewApproved,
+	webhookEventReviewRejected,
+	webhookEventRewardSent,
+	webhookEventStoreCreated,
+}
+
+type webhookSubscriptionDocument struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	URL         string             `bson:"url"`
+	Events      []string           `bson:"events"`
+	Secret      string             `bson:"secret"`
+	Description string             `bson:"description,omitempty"`
+	Active      bool               `bson:"active"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt"`
+}
+
+type webhookDeliveryDocument struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	SubscriptionID primitive.ObjectID `bson:"subscriptionId"`
+	DeliveryID     string             `bson:"deliveryId"`
+	Event          string             `bson:"event"`
+	URL            string             `bson:"url"`
+	Attempt        int                `bson:"attempt"`
+	StatusCode     int                `bson:"statusCode,omitempty"`
+	Error          string             `bson:"error,omitempty"`
+	Success        bool               `bson:"success"`
+	DurationMillis int64              `bson:"durationMillis"`
+	CreatedAt      time.Time          `bson:"createdAt"`
+}
+
+type webhookOutboxDocument struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	SubscriptionID primitive.ObjectID `bson:"subscriptionId"`
+	DeliveryID     string             `bson:"deliveryId"`
+	Event          string             `bson:"event"`
+	Payload        []byte             `bson:"payload"`
+	Attempts       int                `bson:"attempts"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt"`
+	LockedUntil    *time.Time         `bson:"lockedUntil,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt"`
+}
+
+type webhookEnvelope struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+type webhookSubscriptionResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             string    `json:"id"`
+	DeliveryID     string    `json:"deliveryId"`
+	Event          string    `json:"event"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Success        bool      `json:"success"`
+	DurationMillis int64     `json:"durationMillis"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type createWebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+}
+
+type updateWebhookRequest struct {
+	URL          *string   `json:"url"`
+	Events       *[]string `json:"events"`
+	Description  *string   `json:"description"`
+	Active       *bool     `json:"active"`
+	RotateSecret bool      `json:"rotateSecret"`
+}
+
+func validateWebhookURL(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", errors.New("URLは必須です")
+	}
+	parsed, err := url.Parse(trimmed)
+	if err != nil || parsed.Host == "" {
+		return "", errors.New("URLの形式が不正です")
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", errors.New("URLは http または https で指定してください")
+	}
+	return trimmed, nil
+}
+
+func normaliseWebhookEvents(events []string) ([]string, error) {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if !contains(webhookEvents, event) {
+			return nil, fmt.Errorf("未対応のイベントです: %s", event)
+		}
+		if !contains(result, event) {
+			result = append(result, event)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("イベントを1つ以上指定してください")
+	}
+	return result, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookSubscriptionToResponse(doc webhookSubscriptionDocument, includeSecret bool) webhookSubscriptionResponse {
+	resp := webhookSubscriptionResponse{
+		ID:          doc.ID.Hex(),
+		URL:         doc.URL,
+		Events:      append([]string(nil), doc.Events...),
+		Description: doc.Description,
+		Active:      doc.Active,
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
+	}
+	if includeSecret {
+		resp.Secret = doc.Secret
+	}
+	return resp
+}
+
+func (s *server) ensureWebhookOutboxIndex(ctx context.Context) error {
+	_, err := s.webhookOutbox.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "nextAttemptAt", Value: 1}},
+	})
+	return err
+}
+
+func (s *server) emitWebhookEvent(event string, data any) {
+	if s.webhooks == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := s.webhooks.Find(ctx, bson.M{"active": true, "events": event})
+	if err != nil {
+		s.logger.Printf("Webhook 購読の取得に失敗 event=%s err=%v", event, err)
+		return
+	}
+	var subscriptions []webhookSubscriptionDocument
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		s.logger.Printf("Webhook 購読のデコードに失敗 event=%s err=%v", event, err)
+		return
+	}
+
+	now := time.Now().In(s.location)
+	for _, subscription := range subscriptions {
+		envelope := webhookEnvelope{
+			ID:         primitive.NewObjectID().Hex(),
+			Event:      event,
+			OccurredAt: now,
+			Data:       data,
+		}
+		body, err := json.Marshal(envelope)
+		if err != nil {
+			s.logger.Printf("Webhook ペイロードの作成に失敗 subscription=%s err=%v", subscription.ID.Hex(), err)
+			continue
+		}
+		doc := webhookOutboxDocument{
+			ID:             primitive.NewObjectID(),
+			SubscriptionID: subscription.ID,
+			DeliveryID:     envelope.ID,
+			Event:          event,
+			Payload:        body,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		if _, err := s.webhookOutbox.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("Webhook 配信キューへの登録に失敗 subscription=%s event=%s err=%v", subscription.ID.Hex(), event, err)
+			continue
+		}
+		go s.attemptWebhookDelivery(doc.ID)
+	}
+}
+
+func (s *server) runWebhookDispatcher(ctx context.Context) {
+	interval := s.webhookRetryBase
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDueWebhooks(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *server) dispatchDueWebhooks(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"nextAttemptAt": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"lockedUntil": bson.M{"$exists": false}},
+			bson.M{"lockedUntil": bson.M{"$lt": now}},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetLimit(100)
+	cursor, err := s.webhookOutbox.Find(ctx, filter, opts)
+	if err != nil {
+		s.logger.Printf("Webhook 配信キューの取得に失敗: %v", err)
+		return
+	}
+	var due []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &due); err != nil {
+		s.logger.Printf("Webhook 配信キューのデコードに失敗: %v", err)
+		return
+	}
+	for _, item := range due {
+		go s.attemptWebhookDelivery(item.ID)
+	}
+}
+
+func (s *server) attemptWebhookDelivery(id primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	lockedUntil := now.Add(s.webhookClient.Timeout + 30*time.Second)
+	var doc webhookOutboxDocument
+	err := s.webhookOutbox.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "$or": bson.A{
+			bson.M{"lockedUntil": bson.M{"$exists": false}},
+			bson.M{"lockedUntil": bson.M{"$lt": now}},
+		}},
+		bson.M{"$set": bson.M{"lockedUntil": lockedUntil}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			s.logger.Printf("Webhook 配信キューの確保に失敗 id=%s err=%v", id.Hex(), err)
+		}
+		return
+	}
+
+	var subscription webhookSubscriptionDocument
+	if err := s.webhooks.FindOne(ctx, bson.M{"_id": doc.SubscriptionID, "active": true}).Decode(&subscription); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			s.removeWebhookOutbox(doc.ID)
+			return
+		}
+		s.logger.Printf("Webhook 購読の取得に失敗 subscription=%s err=%v", doc.SubscriptionID.Hex(), err)
+		s.rescheduleWebhookOutbox(doc, doc.Attempts)
+		return
+	}
+
+	attempt := doc.Attempts + 1
+	statusCode, retryable, err := s.postWebhook(subscription, doc.DeliveryID, doc.Event, doc.Payload, attempt)
+	if err == nil {
+		s.removeWebhookOutbox(doc.ID)
+		return
+	}
+	if !retryable || attempt >= s.webhookMaxAttempts {
+		s.logger.Printf("Webhook 配信を中止 subscription=%s delivery=%s status=%d attempt=%d err=%v", subscription.ID.Hex(), doc.DeliveryID, statusCode, attempt, err)
+		s.removeWebhookOutbox(doc.ID)
+		return
+	}
+	s.rescheduleWebhookOutbox(doc, attempt)
+}
+
+func (s *server) rescheduleWebhookOutbox(doc webhookOutboxDocument, attempts int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	backoff := s.webhookRetryBase
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+	}
+	_, err := s.webhookOutbox.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{
+		"$set":   bson.M{"attempts": attempts, "nextAttemptAt": time.Now().Add(backoff)},
+		"$unset": bson.M{"lockedUntil": ""},
+	})
+	if err != nil {
+		s.logger.Printf("Webhook 再送予定の保存に失敗 delivery=%s err=%v", doc.DeliveryID, err)
+	}
+}
+
+func (s *server) removeWebhookOutbox(id primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := s.webhookOutbox.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		s.logger.Printf("Webhook 配信キューの削除に失敗 id=%s err=%v", id.Hex(), err)
+	}
+}
+
+func (s *server) postWebhook(subscription webhookSubscriptionDocument, deliveryID, event string, body []byte, attempt int) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.webhookClient.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	timestamp := started.Unix()
+
+	statusCode := 0
+	retryable := true
+	var deliveryErr error
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		deliveryErr = fmt.Errorf("Webhook リクエストの作成に失敗: %w", err)
+		retryable = false
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "makoto-club-webhook/1")
+		req.Header.Set("X-Makoto-Event", event)
+		req.Header.Set("X-Makoto-Delivery", deliveryID)
+		req.Header.Set("X-Makoto-Timestamp", strconv.FormatInt(timestamp, 10))
+		req.Header.Set("X-Makoto-Signature", signWebhookPayload(subscription.Secret, timestamp, body))
+
+		res, err := s.webhookClient.Do(req)
+		if err != nil {
+			deliveryErr = fmt.Errorf("Webhook リクエストに失敗: %w", err)
+		} else {
+			statusCode = res.StatusCode
+			message, _ := io.ReadAll(io.LimitReader(res.Body, 1<<12))
+			res.Body.Close()
+			if res.StatusCode >= 300 {
+				deliveryErr = fmt.Errorf("Webhook 配信先がエラーを返しました: status=%d body=%s", res.StatusCode, strings.TrimSpace(string(message)))
+				retryable = res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+			}
+		}
+	}
+
+	record := webhookDeliveryDocument{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: subscription.ID,
+		DeliveryID:     deliveryID,
+		Event:          event,
+		URL:            subscription.URL,
+		Attempt:        attempt,
+		StatusCode:     statusCode,
+		Success:        deliveryErr == nil,
+		DurationMillis: time.Since(started).Milliseconds(),
+		CreatedAt:      time.Now().In(s.location),
+	}
+	if deliveryErr != nil {
+		record.Error = deliveryErr.Error()
+	}
+
+	logCtx, logCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer logCancel()
+	if _, err := s.webhookDeliveries.InsertOne(logCtx, record); err != nil {
+		s.logger.Printf("Webhook 配信ログの保存に失敗 delivery=%s err=%v", deliveryID, err)
+	}
+
+	return statusCode, retryable, deliveryErr
+}
+
+func (s *server) adminWebhookListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		cursor, err := s.webhooks.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+		if err != nil {
+			s.logger.Printf("admin webhook list find failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook 一覧の取得に失敗しました"})
+			return
+		}
+		var docs []webhookSubscriptionDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.logger.Printf("admin webhook list decode failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook 一覧の取得に失敗しました"})
+			return
+		}
+
+		items := make([]webhookSubscriptionResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, webhookSubscriptionToResponse(doc, false))
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"items":  items,
+			"events": webhookEvents,
+		})
+	}
+}
+
+func (s *server) adminWebhookCreateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req createWebhookRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		endpoint, err := validateWebhookURL(req.URL)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		events, err := normaliseWebhookEvents(req.Events)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			s.logger.Printf("admin webhook create secret generation failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook の作成に失敗しました"})
+			return
+		}
+
+		now := time.Now().In(s.location)
+		doc := webhookSubscriptionDocument{
+			ID:          primitive.NewObjectID(),
+			URL:         endpoint,
+			Events:      events,
+			Secret:      secret,
+			Description: strings.TrimSpace(req.Description),
+			Active:      true,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if _, err := s.webhooks.InsertOne(ctx, doc); err != nil {
+			s.logger.Printf("admin webhook create insert failed: %v", err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook の作成に失敗しました"})
+			return
+		}
+
+		s.logger.Printf("admin webhook create success id=%s url=%q events=%v", doc.ID.Hex(), doc.URL, doc.Events)
+		s.writeJSON(w, http.StatusCreated, webhookSubscriptionToResponse(doc, true))
+	}
+}
+
+func (s *server) adminWebhookUpdateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Webhook IDの形式が不正です"})
+			return
+		}
+
+		var req updateWebhookRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewRequestBody)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "リクエストの形式が不正です"})
+			return
+		}
+
+		update := bson.M{}
+		if req.URL != nil {
+			endpoint, err := validateWebhookURL(*req.URL)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			update["url"] = endpoint
+		}
+		if req.Events != nil {
+			events, err := normaliseWebhookEvents(*req.Events)
+			if err != nil {
+				s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			update["events"] = events
+		}
+		if req.Description != nil {
+			update["description"] = strings.TrimSpace(*req.Description)
+		}
+		if req.Active != nil {
+			update["active"] = *req.Active
+		}
+		if req.RotateSecret {
+			secret, err := generateWebhookSecret()
+			if err != nil {
+				s.logger.Printf("admin webhook update secret generation failed: %v", err)
+				s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook の更新に失敗しました"})
+				return
+			}
+			update["secret"] = secret
+		}
+		if len(update) == 0 {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "更新内容が指定されていません"})
+			return
+		}
+		update["updatedAt"] = time.Now().In(s.location)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var updated webhookSubscriptionDocument
+		result := s.webhooks.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": update}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+		if err := result.Decode(&updated); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook が見つかりません"})
+				return
+			}
+			s.logger.Printf("admin webhook update failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook の更新に失敗しました"})
+			return
+		}
+
+		s.writeJSON(w, http.StatusOK, webhookSubscriptionToResponse(updated, req.RotateSecret))
+	}
+}
+
+func (s *server) adminWebhookDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Webhook IDの形式が不正です"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		result, err := s.webhooks.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			s.logger.Printf("admin webhook delete failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Webhook の削除に失敗しました"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			s.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook が見つかりません"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) adminWebhookDeliveryListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		objectID, err := primitive.ObjectIDFromHex(strings.TrimSpace(chi.URLParam(r, "id")))
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Webhook IDの形式が不正です"})
+			return
+		}
+		limit, _ := parsePositiveInt(r.URL.Query().Get("limit"), 50)
+		if limit > 200 {
+			limit = 200
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit))
+		cursor, err := s.webhookDeliveries.Find(ctx, bson.M{"subscriptionId": objectID}, opts)
+		if err != nil {
+			s.logger.Printf("admin webhook delivery list find failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "配信履歴の取得に失敗しました"})
+			return
+		}
+		var docs []webhookDeliveryDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			s.logger.Printf("admin webhook delivery list decode failed id=%s err=%v", objectID.Hex(), err)
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "配信履歴の取得に失敗しました"})
+			return
+		}
+
+		items := make([]webhookDeliveryResponse, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, webhookDeliveryResponse{
+				ID:             doc.ID.Hex(),
+				DeliveryID:     doc.DeliveryID,
+				Event:          doc.Event,
+				Attempt:        doc.Attempt,
+				StatusCode:     doc.StatusCode,
+				Error:          doc.Error,
+				Success:        doc.Success,
+				DurationMillis: doc.DurationMillis,
+				CreatedAt:      doc.CreatedAt,
+			})
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	cases := []struct {
+		name      string
+		sheetName string
+		rows      [][]any
+		wantSheet []string
+		wantBook  string
+	}{
+		{
+			name:      "文字列と数値",
+			sheetName: "rewards",
+			rows:      [][]any{{"reviewId", "amount"}, {"64b000000000000000000001", 1000}},
+			wantSheet: []string{
+				`<c r="A1" t="inlineStr"><is><t xml:space="preserve">reviewId</t></is></c>`,
+				`<c r="B2"><v>1000</v></c>`,
+			},
+			wantBook: `name="rewards"`,
+		},
+		{
+			name:      "XMLのエスケープ",
+			sheetName: "a&b",
+			rows:      [][]any{{"<店舗>&\"支店\""}},
+			wantSheet: []string{`&lt;店舗&gt;&amp;&#34;支店&#34;`},
+			wantBook:  `name="a&amp;b"`,
+		},
+		{
+			name:      "27列目はAA",
+			sheetName: "wide",
+			rows:      [][]any{make([]any, 27)},
+			wantSheet: []string{`<c r="Z1"`, `<c r="AA1"`},
+			wantBook:  `name="wide"`,
+		},
+		{
+			name:      "空のシート",
+			sheetName: "empty",
+			rows:      nil,
+			wantSheet: []string{`<sheetData></sheetData>`},
+			wantBook:  `name="empty"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeXLSX(&buf, tc.sheetName, tc.rows); err != nil {
+				t.Fatalf("writeXLSX() err = %v", err)
+			}
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("zip.NewReader() err = %v", err)
+			}
+			parts := map[string]string{}
+			for _, file := range archive.File {
+				rc, err := file.Open()
+				if err != nil {
+					t.Fatalf("%s を開けません: %v", file.Name, err)
+				}
+				body, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatalf("%s を読めません: %v", file.Name, err)
+				}
+				decoder := xml.NewDecoder(bytes.NewReader(body))
+				for {
+					if _, err := decoder.Token(); err == io.EOF {
+						break
+					} else if err != nil {
+						t.Fatalf("%s が不正なXMLです: %v", file.Name, err)
+					}
+				}
+				parts[file.Name] = string(body)
+			}
+			for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+				if _, ok := parts[name]; !ok {
+					t.Errorf("%s がありません", name)
+				}
+			}
+			for _, want := range tc.wantSheet {
+				if !strings.Contains(parts["xl/worksheets/sheet1.xml"], want) {
+					t.Errorf("sheet1.xml に %s が含まれていません", want)
+				}
+			}
+			if !strings.Contains(parts["xl/workbook.xml"], tc.wantBook) {
+				t.Errorf("workbook.xml に %s が含まれていません", tc.wantBook)
+			}
+		})
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := xlsxColumnName(index); got != want {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}